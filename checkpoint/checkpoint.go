@@ -0,0 +1,181 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// Store persists the progress of a crawl below a directory so an interrupted
+// crawl can be resumed: the discovered IDs, every completed product and the
+// products that failed. It is safe for concurrent use.
+type Store struct {
+	dir string
+
+	mu     sync.Mutex
+	failed map[string]string
+}
+
+// Open returns a Store rooted at dir, creating the directory when needed.
+// Failures recorded by a previous run are loaded so they can be reported.
+func Open(dir string) (*Store, error) {
+	err := os.MkdirAll(filepath.Join(dir, "products"), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, failed: map[string]string{}}
+
+	data, err := os.ReadFile(s.failedPath())
+	if err == nil {
+		err = json.Unmarshal(data, &s.failed)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reset discards all saved progress so a new crawl starts from scratch.
+func (s *Store) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failed = map[string]string{}
+	for _, path := range []string{s.idsPath(), s.discoveryPath(), s.failedPath(), filepath.Join(s.dir, "products")} {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return os.MkdirAll(filepath.Join(s.dir, "products"), 0o755)
+}
+
+// SaveIDs stores the discovered product IDs.
+func (s *Store) SaveIDs(productIDs []string) error {
+	return writeJSON(s.idsPath(), productIDs)
+}
+
+// SaveDiscovery stores the filters the IDs were discovered with.
+func (s *Store) SaveDiscovery(discovery model.Discovery) error {
+	return writeJSON(s.discoveryPath(), discovery)
+}
+
+// LoadDiscovery returns the filters stored by SaveDiscovery.
+// The error wraps os.ErrNotExist when nothing has been saved yet.
+func (s *Store) LoadDiscovery() (model.Discovery, error) {
+	var discovery model.Discovery
+
+	data, err := os.ReadFile(s.discoveryPath())
+	if err != nil {
+		return discovery, err
+	}
+
+	err = json.Unmarshal(data, &discovery)
+	return discovery, err
+}
+
+// LoadIDs returns the product IDs stored by SaveIDs.
+// The error wraps os.ErrNotExist when nothing has been saved yet.
+func (s *Store) LoadIDs() ([]string, error) {
+	data, err := os.ReadFile(s.idsPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var productIDs []string
+	err = json.Unmarshal(data, &productIDs)
+	return productIDs, err
+}
+
+// SaveProduct stores a successfully fetched product and clears any earlier failure of it.
+func (s *Store) SaveProduct(product *model.Product) error {
+	err := writeJSON(s.productPath(product.ID), product)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.failed[product.ID]; !ok {
+		return nil
+	}
+	delete(s.failed, product.ID)
+	return writeJSON(s.failedPath(), s.failed)
+}
+
+// LoadProduct returns the stored product with the given ID.
+// The error wraps os.ErrNotExist when the product has not been fetched yet.
+func (s *Store) LoadProduct(productID string) (*model.Product, error) {
+	data, err := os.ReadFile(s.productPath(productID))
+	if err != nil {
+		return nil, err
+	}
+
+	var product model.Product
+	err = json.Unmarshal(data, &product)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// MarkFailed records that fetching productID failed with err.
+func (s *Store) MarkFailed(productID string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failed[productID] = err.Error()
+	return writeJSON(s.failedPath(), s.failed)
+}
+
+// Failed returns the recorded failures keyed by product ID.
+func (s *Store) Failed() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := make(map[string]string, len(s.failed))
+	for id, reason := range s.failed {
+		failed[id] = reason
+	}
+	return failed
+}
+
+func (s *Store) idsPath() string {
+	return filepath.Join(s.dir, "ids.json")
+}
+
+func (s *Store) discoveryPath() string {
+	return filepath.Join(s.dir, "discovery.json")
+}
+
+func (s *Store) failedPath() string {
+	return filepath.Join(s.dir, "failed.json")
+}
+
+func (s *Store) productPath(productID string) string {
+	return filepath.Join(s.dir, "products", filepath.Base(productID)+".json")
+}
+
+// writeJSON writes v to path through a temporary file, so an interruption
+// never leaves a truncated checkpoint behind.
+func writeJSON(path string, v any) error {
+	jsonData, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, jsonData, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/product"
+)
+
+// newCommand returns the flag set of a subcommand together with its configuration,
+// loaded from the file named by -config (or $CRAWLING_CONFIG) and the environment.
+// Flags registered afterwards default to the loaded values and override them on parse.
+func newCommand(name, synopsis string, args []string) (*flag.FlagSet, *config.Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: crawling %s %s\n\nFlags:\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+
+	path := configPath(args)
+	fs.String("config", path, "JSON config file, CRAWLING_* environment variables override it")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fs, &cfg, nil
+}
+
+// configPath finds the -config flag in args before they are parsed.
+func configPath(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv("CRAWLING_CONFIG")
+}
+
+// parse parses args into fs and validates the resulting configuration.
+func parse(fs *flag.FlagSet, args []string, cfg *config.Config) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
+
+// addClientFlags registers the HTTP client settings on fs.
+func addClientFlags(fs *flag.FlagSet, h *config.HTTP) {
+	fs.BoolVar(&h.IgnoreRobots, "ignore-robots", h.IgnoreRobots, "fetch URLs even when robots.txt disallows them")
+	fs.StringVar(&h.UserAgent, "user-agent", h.UserAgent, "User-Agent header sent with every request")
+	fs.StringVar(&h.AcceptLanguage, "accept-language", h.AcceptLanguage, "Accept-Language header sent with every request")
+	fs.Var(&h.Timeout, "timeout", "overall timeout of a single request attempt")
+}
+
+// addRulesFlag registers the extraction rules file on fs.
+func addRulesFlag(fs *flag.FlagSet, s *config.Site) {
+	fs.StringVar(&s.Rules, "rules", s.Rules, "selector rules file, empty uses the built-in rules")
+}
+
+// addReviewFlags registers the review settings on fs.
+func addReviewFlags(fs *flag.FlagSet, r *config.Reviews) {
+	fs.IntVar(&r.Max, "max-reviews", r.Max, "most reviews fetched per product, 0 for all")
+	fs.StringVar(&r.Sort, "review-sort", r.Sort, "review order: newest or helpful, empty for the site default")
+}
+
+// addDiscoveryFlags registers the discovery source and filters on fs.
+func addDiscoveryFlags(fs *flag.FlagSet, c *config.Crawl) {
+	fs.StringVar(&c.Source, "source", c.Source, "discovery source: "+strings.Join(config.Sources, ", "))
+	fs.Func("keyword", "search term whose results are merged into the IDs, repeatable", func(value string) error {
+		c.Keywords = append(c.Keywords, value)
+		return nil
+	})
+	fs.StringVar(&c.ModifiedSince, "modified-since", c.ModifiedSince, "sitemap source: skip products modified before this date (YYYY-MM-DD)")
+
+	q := &c.Query
+	fs.StringVar(&q.Gender, "gender", q.Gender, "gender filter, e.g. mens, womens, kids; the list source defaults to "+config.DefaultListGender)
+	fs.StringVar(&q.Category, "category", q.Category, "category filter, e.g. footwear, wear")
+	fs.StringVar(&q.BrandLine, "brand-line", q.BrandLine, "brand line filter, e.g. originals")
+	fs.IntVar(&q.MinPrice, "min-price", q.MinPrice, "lowest price in yen")
+	fs.IntVar(&q.MaxPrice, "max-price", q.MaxPrice, "highest price in yen")
+	fs.StringVar(&q.Sort, "sort", q.Sort, "sort order of the list API")
+	fs.IntVar(&q.PageSize, "page-size", q.PageSize, "IDs requested per page, 0 uses the site default")
+	fs.Func("param", "extra list API parameter as key=value, repeatable", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected key=value")
+		}
+		if q.Extra == nil {
+			q.Extra = map[string]string{}
+		}
+		q.Extra[key] = val
+		return nil
+	})
+}
+
+// addOutputFlags registers the export settings on fs.
+func addOutputFlags(fs *flag.FlagSet, out *config.Output) {
+	fs.Var((*listValue)(&out.Formats), "format", "comma separated output formats: "+strings.Join(config.Formats, ", "))
+	fs.StringVar(&out.JSONPath, "json-out", out.JSONPath, "path of the JSON output")
+	fs.StringVar(&out.XLSXPath, "xlsx-out", out.XLSXPath, "path of the spreadsheet output")
+	fs.StringVar(&out.CSVPath, "csv-out", out.CSVPath, "path of the CSV output")
+	fs.StringVar(&out.Template, "template", out.Template, "spreadsheet template")
+	fs.StringVar(&out.Discovery, "discovery-out", out.Discovery, "where the discovery filters are recorded, empty to skip")
+	fs.Var((*listValue)(&out.Fit), "fit", "comma separated size fits to export, any of: "+strings.Join(model.FitLabels, ", "))
+}
+
+// listValue is a flag.Value holding a comma separated list.
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = strings.Split(value, ",")
+	return nil
+}
+
+// interruptContext returns a context cancelled by the first SIGINT/SIGTERM.
+// A second signal kills the process.
+func interruptContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		fmt.Fprintln(os.Stderr, "Interrupted: finishing in-flight products, press Ctrl-C again to quit immediately")
+	}()
+
+	return ctx
+}
+
+// readIDs reads product IDs from a file with one ID per line; blank lines and # comments are ignored.
+func readIDs(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var productIDs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if id := strings.TrimSpace(line); id != "" {
+			productIDs = append(productIDs, id)
+		}
+	}
+
+	return productIDs, scanner.Err()
+}
+
+// collect keeps the successfully fetched products in order and reports the failed ones.
+func collect(productIDs []string, results []*model.Product, errs []error) []model.Product {
+	var products []model.Product
+	for i := 0; i < len(results); i++ {
+		if errs[i] != nil {
+			fmt.Fprintln(os.Stderr, "Skipping product", productIDs[i], ":", errs[i])
+			continue
+		}
+		products = append(products, *results[i])
+	}
+	return products
+}
+
+// gatherIDs discovers product IDs from the source selected in cfg and merges in
+// the results of the configured search keywords.
+func gatherIDs(ctx context.Context, client *helper.Client, cfg *config.Config) ([]string, model.Discovery, error) {
+	c := cfg.Crawl
+
+	var searchIDs []string
+	var search model.Discovery
+	var err error
+	if len(c.Keywords) > 0 {
+		searchIDs, search, err = product.SearchIDs(ctx, client, cfg.Site, c.Query, c.Keywords, c.Limit)
+		if c.Source == "search" || err != nil {
+			return searchIDs, search, err
+		}
+	}
+
+	var productIDs []string
+	var discovery model.Discovery
+	switch c.Source {
+	case "sitemap":
+		// validated together with the rest of the config
+		since, _ := c.Since()
+		productIDs, discovery, err = product.GatherSitemapIDs(ctx, client, cfg.Site, c.Limit, since)
+	default:
+		productIDs, discovery, err = product.GatherIDs(ctx, client, cfg.Site, c.ListQuery(), c.Limit)
+	}
+
+	if len(c.Keywords) > 0 {
+		productIDs, discovery = product.MergeDiscovery(productIDs, discovery, searchIDs, search, c.Limit)
+	}
+	return productIDs, discovery, err
+}
+
+// reportSkipped lists the URLs the client refused to fetch because of robots.txt.
+func reportSkipped(client *helper.Client) {
+	if skipped := client.Robots.Skipped(); len(skipped) > 0 {
+		fmt.Fprintln(os.Stderr, "Skipped", len(skipped), "URLs disallowed by robots.txt:")
+		for _, u := range skipped {
+			fmt.Fprintln(os.Stderr, "  ", u)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// Config holds every setting of a crawl. It is built from Default, overlaid by
+// a JSON config file and then by CRAWLING_* environment variables.
+type Config struct {
+	Site   Site   `json:"site"`
+	HTTP   HTTP   `json:"http"`
+	Crawl  Crawl  `json:"crawl"`
+	Output Output `json:"output"`
+}
+
+// Site describes the endpoints the product package crawls.
+type Site struct {
+	Host         string `json:"host"`           // shop origin, product pages live below /products/{id}/
+	ListAPI      string `json:"list_api"`       // product list used for discovery
+	SizeChartAPI string `json:"size_chart_api"` // size chart, the model code is appended
+	ReviewAPI    string `json:"review_api"`     // Bazaarvoice base including its locale
+	Sitemap      string `json:"sitemap"`        // sitemap index used by sitemap discovery
+	SearchAPI    string `json:"search_api"`     // keyword search, takes the list API parameters plus q
+	PageSize     int    `json:"page_size"`      // IDs requested per list page unless the query sets one
+	Rules        string `json:"rules"`          // selector rules file, empty uses the built-in rules
+}
+
+// HTTP configures the shared HTTP client.
+type HTTP struct {
+	UserAgent      string            `json:"user_agent"`
+	AcceptLanguage string            `json:"accept_language"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	ConnectTimeout Duration          `json:"connect_timeout"`
+	ReadTimeout    Duration          `json:"read_timeout"`
+	Timeout        Duration          `json:"timeout"`
+	MaxAttempts    int               `json:"max_attempts"`
+	IgnoreRobots   bool              `json:"ignore_robots"`
+
+	BaseDelay       Duration `json:"base_delay"`       // wait before the first retry, doubled on every further retry
+	MaxDelay        Duration `json:"max_delay"`        // longest wait between two attempts
+	Jitter          float64  `json:"jitter"`           // fraction (0..1) of a wait that is randomized
+	RetryableStatus []int    `json:"retryable_status"` // response statuses worth another attempt
+
+	MaxIdleConns        int `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int `json:"max_conns_per_host"` // zero means no limit
+
+	RateLimit      RateLimit            `json:"rate_limit"`                 // hosts not listed in HostRateLimits
+	HostRateLimits map[string]RateLimit `json:"host_rate_limits,omitempty"` // keyed by host name
+}
+
+// RateLimit configures how fast requests may be sent to a host.
+type RateLimit struct {
+	RequestsPerSecond float64  `json:"requests_per_second"` // zero or less disables the token bucket
+	Burst             int      `json:"burst"`               // requests allowed back to back
+	MinDelay          Duration `json:"min_delay"`           // gap between two consecutive requests, raised by robots.txt Crawl-delay
+}
+
+func (r RateLimit) hostLimit() helper.HostLimit {
+	return helper.HostLimit{RequestsPerSecond: r.RequestsPerSecond, Burst: r.Burst, MinDelay: time.Duration(r.MinDelay)}
+}
+
+func rateLimit(limit helper.HostLimit) RateLimit {
+	return RateLimit{RequestsPerSecond: limit.RequestsPerSecond, Burst: limit.Burst, MinDelay: Duration(limit.MinDelay)}
+}
+
+// Crawl configures discovery and fetching.
+type Crawl struct {
+	Limit          int             `json:"limit"`
+	Source         string          `json:"source"`          // list, sitemap or search
+	Keywords       []string        `json:"keywords"`        // searched and merged into the IDs of the source
+	ModifiedSince  string          `json:"modified_since"`  // sitemap only: skip products older than this date (YYYY-MM-DD or RFC 3339)
+	Query          model.ListQuery `json:"query"`           // filters of the list and search APIs, the list source adds DefaultListGender
+	ExpandVariants bool            `json:"expand_variants"` // also fetch every colorway of the discovered models
+	Concurrency    int             `json:"concurrency"`
+	Reviews        Reviews         `json:"reviews"`
+	Checkpoint     string          `json:"checkpoint"`
+}
+
+// Reviews configures how many reviews are fetched per product and in which order.
+type Reviews struct {
+	Max  int    `json:"max"`  // most reviews kept per product, 0 keeps all
+	Sort string `json:"sort"` // newest, helpful or empty for the Bazaarvoice default
+}
+
+// ReviewSorts maps the review sort orders to the Bazaarvoice sort parameter.
+var ReviewSorts = map[string]string{
+	"newest":  "submissionTime",
+	"helpful": "helpfulness",
+}
+
+// Output configures the export formats and files.
+type Output struct {
+	Formats   []string `json:"formats"`
+	JSONPath  string   `json:"json_path"`
+	XLSXPath  string   `json:"xlsx_path"`
+	CSVPath   string   `json:"csv_path"`
+	Template  string   `json:"template"`
+	Discovery string   `json:"discovery_path"` // records the discovery filters next to the output
+	Fit       []string `json:"fit,omitempty"`  // only export products with one of these size fit labels
+}
+
+// Sources lists the discovery sources of the crawl.
+var Sources = []string{"list", "sitemap", "search"}
+
+// DefaultListGender is the gender the list source is crawled for when the query names none.
+const DefaultListGender = "mens"
+
+// ListQuery returns Query as sent to the list API, which falls back to DefaultListGender.
+// Keyword search uses Query as is so it finds every product matching a term.
+func (c Crawl) ListQuery() model.ListQuery {
+	q := c.Query
+	if q.Gender == "" {
+		q.Gender = DefaultListGender
+	}
+	return q
+}
+
+// Since parses ModifiedSince, returning the zero time when it is empty.
+func (c Crawl) Since() (time.Time, error) {
+	if c.ModifiedSince == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, c.ModifiedSince); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, c.ModifiedSince)
+}
+
+// Formats lists the output formats the export package can write.
+var Formats = []string{"json", "xlsx", "csv"}
+
+// Duration is a time.Duration written as a string such as "30s" in config files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	return d.Set(s)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Set parses a duration such as "1m30s".
+func (d *Duration) Set(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the built-in settings that a config file, the environment and flags
+// override, e.g. fetching 8 products at a time and at most 100 reviews per product.
+func Default() Config {
+	client := helper.DefaultClientOptions()
+	hostRateLimits := map[string]RateLimit{}
+	for host, limit := range helper.DefaultHostLimits {
+		hostRateLimits[host] = rateLimit(limit)
+	}
+
+	return Config{
+		Site: Site{
+			Host:         "https://shop.adidas.jp",
+			ListAPI:      "https://shop.adidas.jp/f/v1/pub/product/list",
+			SizeChartAPI: "https://shop.adidas.jp/f/v1/pub/size_chart",
+			ReviewAPI:    "https://adidasjp.ugc.bazaarvoice.com/7896-ja_jp",
+			Sitemap:      "https://shop.adidas.jp/sitemap.xml",
+			SearchAPI:    "https://shop.adidas.jp/f/v1/pub/product/list",
+			PageSize:     120,
+		},
+		HTTP: HTTP{
+			UserAgent:      client.UserAgent,
+			AcceptLanguage: client.AcceptLanguage,
+			ConnectTimeout: Duration(client.ConnectTimeout),
+			ReadTimeout:    Duration(client.ReadTimeout),
+			Timeout:        Duration(client.Timeout),
+			MaxAttempts:    client.Retry.MaxAttempts,
+
+			BaseDelay:       Duration(client.Retry.BaseDelay),
+			MaxDelay:        Duration(client.Retry.MaxDelay),
+			Jitter:          client.Retry.Jitter,
+			RetryableStatus: client.Retry.RetryableStatus,
+
+			MaxIdleConns:        client.MaxIdleConns,
+			MaxIdleConnsPerHost: client.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     client.MaxConnsPerHost,
+
+			RateLimit:      rateLimit(helper.DefaultHostLimit),
+			HostRateLimits: hostRateLimits,
+		},
+		Crawl: Crawl{
+			Limit:       300,
+			Source:      "list",
+			Concurrency: 8,
+			Reviews:     Reviews{Max: 100},
+			Checkpoint:  ".crawl-checkpoint",
+		},
+		Output: Output{
+			Formats:   []string{"json", "xlsx"},
+			JSONPath:  "product.txt",
+			XLSXPath:  "product.xlsx",
+			CSVPath:   "product.csv",
+			Template:  "./template/template.xlsx",
+			Discovery: "discovery.json",
+		},
+	}
+}
+
+// Load returns Default overlaid by the JSON file at path (skipped when path is empty)
+// and by the environment. The result is not validated.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, err
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	err := cfg.applyEnv(os.LookupEnv)
+	return cfg, err
+}
+
+// applyEnv overrides settings with the CRAWLING_* variables found by lookup.
+func (cfg *Config) applyEnv(lookup func(string) (string, bool)) error {
+	str := func(target *string) func(string) error {
+		return func(v string) error { *target = v; return nil }
+	}
+	num := func(target *int) func(string) error {
+		return func(v string) error {
+			n, err := strconv.Atoi(v)
+			*target = n
+			return err
+		}
+	}
+	dur := func(target *Duration) func(string) error {
+		return target.Set
+	}
+	boolean := func(target *bool) func(string) error {
+		return func(v string) error {
+			b, err := strconv.ParseBool(v)
+			*target = b
+			return err
+		}
+	}
+	list := func(target *[]string) func(string) error {
+		return func(v string) error { *target = strings.Split(v, ","); return nil }
+	}
+	float := func(target *float64) func(string) error {
+		return func(v string) error {
+			f, err := strconv.ParseFloat(v, 64)
+			*target = f
+			return err
+		}
+	}
+	nums := func(target *[]int) func(string) error {
+		return func(v string) error {
+			*target = nil
+			for _, item := range strings.Split(v, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil {
+					return err
+				}
+				*target = append(*target, n)
+			}
+			return nil
+		}
+	}
+	// the rate limit variables apply to every host, including the ones with a limit of their own
+	everyHost := func(set func(*RateLimit, string) error) func(string) error {
+		return func(v string) error {
+			if err := set(&cfg.HTTP.RateLimit, v); err != nil {
+				return err
+			}
+			for host, limit := range cfg.HTTP.HostRateLimits {
+				if err := set(&limit, v); err != nil {
+					return err
+				}
+				cfg.HTTP.HostRateLimits[host] = limit
+			}
+			return nil
+		}
+	}
+
+	overrides := []struct {
+		name  string
+		apply func(string) error
+	}{
+		{"CRAWLING_HOST", str(&cfg.Site.Host)},
+		{"CRAWLING_LIST_API", str(&cfg.Site.ListAPI)},
+		{"CRAWLING_SIZE_CHART_API", str(&cfg.Site.SizeChartAPI)},
+		{"CRAWLING_REVIEW_API", str(&cfg.Site.ReviewAPI)},
+		{"CRAWLING_SITEMAP", str(&cfg.Site.Sitemap)},
+		{"CRAWLING_SEARCH_API", str(&cfg.Site.SearchAPI)},
+		{"CRAWLING_PAGE_SIZE", num(&cfg.Site.PageSize)},
+		{"CRAWLING_RULES", str(&cfg.Site.Rules)},
+		{"CRAWLING_USER_AGENT", str(&cfg.HTTP.UserAgent)},
+		{"CRAWLING_ACCEPT_LANGUAGE", str(&cfg.HTTP.AcceptLanguage)},
+		{"CRAWLING_CONNECT_TIMEOUT", dur(&cfg.HTTP.ConnectTimeout)},
+		{"CRAWLING_READ_TIMEOUT", dur(&cfg.HTTP.ReadTimeout)},
+		{"CRAWLING_TIMEOUT", dur(&cfg.HTTP.Timeout)},
+		{"CRAWLING_MAX_ATTEMPTS", num(&cfg.HTTP.MaxAttempts)},
+		{"CRAWLING_IGNORE_ROBOTS", boolean(&cfg.HTTP.IgnoreRobots)},
+		{"CRAWLING_BASE_DELAY", dur(&cfg.HTTP.BaseDelay)},
+		{"CRAWLING_MAX_DELAY", dur(&cfg.HTTP.MaxDelay)},
+		{"CRAWLING_JITTER", float(&cfg.HTTP.Jitter)},
+		{"CRAWLING_RETRYABLE_STATUS", nums(&cfg.HTTP.RetryableStatus)},
+		{"CRAWLING_MAX_IDLE_CONNS", num(&cfg.HTTP.MaxIdleConns)},
+		{"CRAWLING_MAX_IDLE_CONNS_PER_HOST", num(&cfg.HTTP.MaxIdleConnsPerHost)},
+		{"CRAWLING_MAX_CONNS_PER_HOST", num(&cfg.HTTP.MaxConnsPerHost)},
+		{"CRAWLING_REQUESTS_PER_SECOND", everyHost(func(r *RateLimit, v string) error { return float(&r.RequestsPerSecond)(v) })},
+		{"CRAWLING_BURST", everyHost(func(r *RateLimit, v string) error { return num(&r.Burst)(v) })},
+		{"CRAWLING_MIN_DELAY", everyHost(func(r *RateLimit, v string) error { return dur(&r.MinDelay)(v) })},
+		{"CRAWLING_LIMIT", num(&cfg.Crawl.Limit)},
+		{"CRAWLING_SOURCE", str(&cfg.Crawl.Source)},
+		{"CRAWLING_MODIFIED_SINCE", str(&cfg.Crawl.ModifiedSince)},
+		{"CRAWLING_KEYWORDS", list(&cfg.Crawl.Keywords)},
+		{"CRAWLING_GENDER", str(&cfg.Crawl.Query.Gender)},
+		{"CRAWLING_CATEGORY", str(&cfg.Crawl.Query.Category)},
+		{"CRAWLING_BRAND_LINE", str(&cfg.Crawl.Query.BrandLine)},
+		{"CRAWLING_MIN_PRICE", num(&cfg.Crawl.Query.MinPrice)},
+		{"CRAWLING_MAX_PRICE", num(&cfg.Crawl.Query.MaxPrice)},
+		{"CRAWLING_SORT", str(&cfg.Crawl.Query.Sort)},
+		{"CRAWLING_CONCURRENCY", num(&cfg.Crawl.Concurrency)},
+		{"CRAWLING_EXPAND_VARIANTS", boolean(&cfg.Crawl.ExpandVariants)},
+		{"CRAWLING_MAX_REVIEWS", num(&cfg.Crawl.Reviews.Max)},
+		{"CRAWLING_REVIEW_SORT", str(&cfg.Crawl.Reviews.Sort)},
+		{"CRAWLING_CHECKPOINT", str(&cfg.Crawl.Checkpoint)},
+		{"CRAWLING_FORMATS", list(&cfg.Output.Formats)},
+		{"CRAWLING_JSON_PATH", str(&cfg.Output.JSONPath)},
+		{"CRAWLING_XLSX_PATH", str(&cfg.Output.XLSXPath)},
+		{"CRAWLING_CSV_PATH", str(&cfg.Output.CSVPath)},
+		{"CRAWLING_TEMPLATE", str(&cfg.Output.Template)},
+		{"CRAWLING_FIT", list(&cfg.Output.Fit)},
+		{"CRAWLING_DISCOVERY_PATH", str(&cfg.Output.Discovery)},
+	}
+
+	for _, o := range overrides {
+		value, ok := lookup(o.name)
+		if !ok {
+			continue
+		}
+		if err := o.apply(value); err != nil {
+			return fmt.Errorf("%s: %w", o.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (cfg Config) Validate() error {
+	var errs []error
+
+	urls := []struct{ name, value string }{
+		{"site.host", cfg.Site.Host},
+		{"site.list_api", cfg.Site.ListAPI},
+		{"site.size_chart_api", cfg.Site.SizeChartAPI},
+		{"site.review_api", cfg.Site.ReviewAPI},
+		{"site.sitemap", cfg.Site.Sitemap},
+		{"site.search_api", cfg.Site.SearchAPI},
+	}
+	for _, v := range urls {
+		u, err := url.Parse(v.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: %q is not an http(s) URL", v.name, v.value))
+		}
+	}
+
+	if cfg.Site.PageSize < 1 {
+		errs = append(errs, fmt.Errorf("site.page_size: must be positive"))
+	}
+	if _, err := rules.Load(cfg.Site.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("site.rules: %w", err))
+	}
+	if cfg.HTTP.ConnectTimeout < 0 || cfg.HTTP.ReadTimeout < 0 || cfg.HTTP.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("http: timeouts must not be negative"))
+	}
+	if cfg.HTTP.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("http.max_attempts: must be positive"))
+	}
+	if cfg.HTTP.BaseDelay < 0 || cfg.HTTP.MaxDelay < 0 {
+		errs = append(errs, fmt.Errorf("http: retry delays must not be negative"))
+	}
+	if cfg.HTTP.Jitter < 0 || cfg.HTTP.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("http.jitter: must be between 0 and 1"))
+	}
+	for _, status := range cfg.HTTP.RetryableStatus {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("http.retryable_status: %d is not an HTTP status", status))
+		}
+	}
+	if cfg.HTTP.MaxIdleConns < 0 || cfg.HTTP.MaxIdleConnsPerHost < 0 || cfg.HTTP.MaxConnsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("http: connection pool sizes must not be negative"))
+	}
+	limits := map[string]RateLimit{"http.rate_limit": cfg.HTTP.RateLimit}
+	for host, limit := range cfg.HTTP.HostRateLimits {
+		limits["http.host_rate_limits."+host] = limit
+	}
+	for _, name := range slices.Sorted(maps.Keys(limits)) {
+		if limit := limits[name]; limit.RequestsPerSecond < 0 || limit.Burst < 0 || limit.MinDelay < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative", name))
+		}
+	}
+	if cfg.Crawl.Limit < 1 {
+		errs = append(errs, fmt.Errorf("crawl.limit: must be positive"))
+	}
+	if !slices.Contains(Sources, cfg.Crawl.Source) {
+		errs = append(errs, fmt.Errorf("crawl.source: unknown source %q", cfg.Crawl.Source))
+	}
+	if cfg.Crawl.Source == "search" && len(cfg.Crawl.Keywords) == 0 {
+		errs = append(errs, fmt.Errorf("crawl.keywords: required for the search source"))
+	}
+	if _, err := cfg.Crawl.Since(); err != nil {
+		errs = append(errs, fmt.Errorf("crawl.modified_since: %w", err))
+	}
+	if q := cfg.Crawl.Query; q.MinPrice < 0 || q.MaxPrice < 0 || (q.MaxPrice > 0 && q.MinPrice > q.MaxPrice) {
+		errs = append(errs, fmt.Errorf("crawl.query: invalid price range %d-%d", q.MinPrice, q.MaxPrice))
+	}
+	if cfg.Crawl.Query.PageSize < 0 {
+		errs = append(errs, fmt.Errorf("crawl.query.page_size: must not be negative"))
+	}
+	if cfg.Crawl.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("crawl.concurrency: must be positive"))
+	}
+	if cfg.Crawl.Reviews.Max < 0 {
+		errs = append(errs, fmt.Errorf("crawl.reviews.max: must not be negative"))
+	}
+	if _, ok := ReviewSorts[cfg.Crawl.Reviews.Sort]; !ok && cfg.Crawl.Reviews.Sort != "" {
+		errs = append(errs, fmt.Errorf("crawl.reviews.sort: unknown sort order %q", cfg.Crawl.Reviews.Sort))
+	}
+
+	for _, format := range cfg.Output.Formats {
+		if !slices.Contains(Formats, format) {
+			errs = append(errs, fmt.Errorf("output.formats: unknown format %q", format))
+		}
+	}
+	for _, fit := range cfg.Output.Fit {
+		if !slices.Contains(model.FitLabels, fit) {
+			errs = append(errs, fmt.Errorf("output.fit: unknown size fit %q", fit))
+		}
+	}
+	if slices.Contains(cfg.Output.Formats, "xlsx") && cfg.Output.Template == "" {
+		errs = append(errs, fmt.Errorf("output.template: required for xlsx output"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ClientOptions returns the helper client options described by the HTTP settings.
+func (h HTTP) ClientOptions() helper.ClientOptions {
+	options := helper.DefaultClientOptions()
+
+	options.UserAgent = h.UserAgent
+	options.AcceptLanguage = h.AcceptLanguage
+	options.Headers = h.Headers
+	options.ConnectTimeout = time.Duration(h.ConnectTimeout)
+	options.ReadTimeout = time.Duration(h.ReadTimeout)
+	options.Timeout = time.Duration(h.Timeout)
+	options.Retry.MaxAttempts = h.MaxAttempts
+	options.Retry.BaseDelay = time.Duration(h.BaseDelay)
+	options.Retry.MaxDelay = time.Duration(h.MaxDelay)
+	options.Retry.Jitter = h.Jitter
+	options.Retry.RetryableStatus = h.RetryableStatus
+	options.MaxIdleConns = h.MaxIdleConns
+	options.MaxIdleConnsPerHost = h.MaxIdleConnsPerHost
+	options.MaxConnsPerHost = h.MaxConnsPerHost
+	options.IgnoreRobots = h.IgnoreRobots
+
+	hostLimits := map[string]helper.HostLimit{}
+	for host, limit := range h.HostRateLimits {
+		hostLimits[host] = limit.hostLimit()
+	}
+	options.Limiter = helper.NewRateLimiter(h.RateLimit.hostLimit(), hostLimits)
+
+	return options
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// runConfig handles "config print", which shows the effective settings after
+// applying the config file, the environment and the given flags.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf(`usage: crawling config print [-config file]`)
+	}
+	args = args[1:]
+
+	fs, cfg, err := newCommand("config print", "[flags]", args)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(cfg, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonData))
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nahidhasan98/crawling/checkpoint"
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/export"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/product"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// runCrawl discovers product IDs, fetches every product and exports the result,
+// checkpointing its progress so an interrupted crawl can be resumed.
+func runCrawl(args []string) error {
+	fs, cfg, err := newCommand("crawl", "[flags]", args)
+	if err != nil {
+		return err
+	}
+	addClientFlags(fs, &cfg.HTTP)
+	fs.IntVar(&cfg.Crawl.Limit, "limit", cfg.Crawl.Limit, "maximum number of products")
+	addDiscoveryFlags(fs, &cfg.Crawl)
+	fs.IntVar(&cfg.Crawl.Concurrency, "concurrency", cfg.Crawl.Concurrency, "number of products fetched in parallel")
+	fs.StringVar(&cfg.Crawl.Checkpoint, "checkpoint", cfg.Crawl.Checkpoint, "directory where crawl progress is saved")
+	resume := fs.Bool("resume", false, "continue the crawl saved in the checkpoint directory")
+	fs.BoolVar(&cfg.Crawl.ExpandVariants, "expand-variants", cfg.Crawl.ExpandVariants, "also fetch every color variant of the discovered models")
+	addRulesFlag(fs, &cfg.Site)
+	addReviewFlags(fs, &cfg.Crawl.Reviews)
+	addOutputFlags(fs, &cfg.Output)
+	if err := parse(fs, args, cfg); err != nil {
+		return err
+	}
+
+	r, err := rules.Load(cfg.Site.Rules)
+	if err != nil {
+		return err
+	}
+
+	client := helper.NewClient(cfg.HTTP.ClientOptions())
+	ctx := interruptContext()
+
+	fmt.Fprintln(os.Stderr, "Programming is running...")
+
+	store, err := checkpoint.Open(cfg.Crawl.Checkpoint)
+	if err != nil {
+		return err
+	}
+
+	productIDs, discovery, err := discover(ctx, client, cfg, store, *resume)
+	if err != nil {
+		if len(productIDs) == 0 {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Error gathering product IDs:", err)
+	}
+	if *resume {
+		reportFailed(store)
+	}
+
+	saved := fetchWithCheckpoint(ctx, client, cfg, r, store, productIDs, *resume)
+	if cfg.Crawl.ExpandVariants {
+		variantIDs := product.VariantIDs(saved, productIDs)
+		if len(variantIDs) > 0 {
+			fmt.Fprintln(os.Stderr, "Expanding to", len(variantIDs), "more color variants")
+			saved = append(saved, fetchWithCheckpoint(ctx, client, cfg, r, store, variantIDs, *resume)...)
+		}
+	}
+
+	var products []model.Product
+	for _, p := range saved {
+		if p != nil {
+			p.SearchQueries = discovery.Matches[p.ID]
+			products = append(products, *p)
+		}
+	}
+	if cfg.Crawl.ExpandVariants {
+		products = product.GroupVariants(products)
+	}
+
+	reportSkipped(client)
+
+	if cfg.Output.Discovery != "" {
+		if err := export.WriteDiscovery(discovery, cfg.Output.Discovery); err != nil {
+			fmt.Fprintln(os.Stderr, "Error recording discovery filters:", err)
+		}
+	}
+
+	return export.Export(products, cfg.Output)
+}
+
+// fetchWithCheckpoint fetches productIDs and saves every outcome to store. When resuming,
+// products finished by an earlier run are taken from the checkpoint instead. The result
+// follows the order of productIDs with nil entries for failed products.
+func fetchWithCheckpoint(ctx context.Context, client *helper.Client, cfg *config.Config, r *rules.Rules, store *checkpoint.Store, productIDs []string, resume bool) []*model.Product {
+	saved := make([]*model.Product, len(productIDs))
+	var pending []int
+	for i, id := range productIDs {
+		if resume {
+			var err error
+			saved[i], err = store.LoadProduct(id)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(os.Stderr, "Ignoring unreadable checkpoint of", id, ":", err)
+			}
+		}
+		pending = append(pending, i)
+	}
+	if resume {
+		fmt.Fprintln(os.Stderr, "Resuming:", len(productIDs)-len(pending), "products already fetched,", len(pending), "left")
+	}
+
+	pendingIDs := make([]string, len(pending))
+	for i, idx := range pending {
+		pendingIDs[i] = productIDs[idx]
+	}
+
+	results, errs := product.FetchAll(ctx, client, cfg.Site, r, cfg.Crawl.Reviews, pendingIDs, cfg.Crawl.Concurrency, func(i int, p *model.Product, err error) {
+		if err != nil {
+			err = store.MarkFailed(pendingIDs[i], err)
+		} else {
+			err = store.SaveProduct(p)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving checkpoint of", pendingIDs[i], ":", err)
+		}
+	})
+	for i, idx := range pending {
+		if errs[i] != nil {
+			fmt.Fprintln(os.Stderr, "Skipping product", pendingIDs[i], ":", errs[i])
+			continue
+		}
+		saved[idx] = results[i]
+	}
+
+	return saved
+}
+
+// discover returns the product IDs to crawl and the filters they were found with.
+// A resumed crawl reuses what the checkpoint saved, refusing to go on when the
+// discovery settings changed since. A new crawl gathers the IDs afresh. The IDs are
+// only saved once discovery finished, so a resumed crawl whose discovery was cut
+// short discovers again, keeping the products fetched so far.
+func discover(ctx context.Context, client *helper.Client, cfg *config.Config, store *checkpoint.Store, resume bool) ([]string, model.Discovery, error) {
+	if resume {
+		productIDs, err := store.LoadIDs()
+		if err == nil {
+			discovery, err := store.LoadDiscovery()
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, discovery, err
+			}
+			if err == nil {
+				if changes := discoveryChanges(discovery, cfg.Crawl); len(changes) > 0 {
+					return nil, discovery, fmt.Errorf("the checkpoint was discovered with other settings (%s); run without -resume to start over", strings.Join(changes, ", "))
+				}
+			}
+			return productIDs, discovery, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, model.Discovery{}, err
+		}
+		fmt.Fprintln(os.Stderr, "No complete list of product IDs saved, discovering them again")
+	} else {
+		err := store.Reset()
+		if err != nil {
+			return nil, model.Discovery{}, err
+		}
+	}
+
+	productIDs, discovery, err := gatherIDs(ctx, client, cfg)
+	if err != nil {
+		if len(productIDs) > 0 {
+			fmt.Fprintln(os.Stderr, "Discovery did not finish, -resume will discover the product IDs again")
+		}
+		return productIDs, discovery, err
+	}
+
+	if saveErr := store.SaveDiscovery(discovery); saveErr != nil {
+		fmt.Fprintln(os.Stderr, "Error saving checkpoint of discovery filters:", saveErr)
+	}
+	if saveErr := store.SaveIDs(productIDs); saveErr != nil {
+		fmt.Fprintln(os.Stderr, "Error saving checkpoint of product IDs:", saveErr)
+	}
+	return productIDs, discovery, nil
+}
+
+// discoveryChanges lists the discovery settings of c that differ from the ones a
+// checkpoint was discovered with.
+func discoveryChanges(saved model.Discovery, c config.Crawl) []string {
+	var changes []string
+
+	if saved.Source != c.Source {
+		changes = append(changes, fmt.Sprintf("source %s, now %s", saved.Source, c.Source))
+	}
+	if saved.Requested != c.Limit {
+		changes = append(changes, fmt.Sprintf("limit %d, now %d", saved.Requested, c.Limit))
+	}
+	if !slices.Equal(saved.Keywords, c.Keywords) {
+		changes = append(changes, fmt.Sprintf("keywords %q, now %q", saved.Keywords, c.Keywords))
+	}
+
+	query := c.ListQuery()
+	if c.Source == "search" {
+		query = c.Query
+	}
+	if c.Source != "sitemap" {
+		before, _ := json.Marshal(saved.Query)
+		now, _ := json.Marshal(query)
+		if !bytes.Equal(before, now) {
+			changes = append(changes, fmt.Sprintf("query %s, now %s", before, now))
+		}
+	}
+
+	return changes
+}
+
+// reportFailed lists the products an earlier run of a resumed crawl failed to fetch.
+// They are fetched again.
+func reportFailed(store *checkpoint.Store) {
+	failed := store.Failed()
+	if len(failed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Retrying", len(failed), "products that failed before:")
+	for _, id := range slices.Sorted(maps.Keys(failed)) {
+		fmt.Fprintln(os.Stderr, "  ", id, ":", failed[id])
+	}
+}
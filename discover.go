@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nahidhasan98/crawling/export"
+	"github.com/nahidhasan98/crawling/helper"
+)
+
+// runDiscover gathers product IDs from the list API or the sitemaps and prints them one per line.
+func runDiscover(args []string) error {
+	fs, cfg, err := newCommand("discover", "[flags]", args)
+	if err != nil {
+		return err
+	}
+	addClientFlags(fs, &cfg.HTTP)
+	fs.IntVar(&cfg.Crawl.Limit, "limit", cfg.Crawl.Limit, "maximum number of product IDs")
+	addDiscoveryFlags(fs, &cfg.Crawl)
+	output := fs.String("o", "", "write the IDs to this file instead of stdout")
+	fs.StringVar(&cfg.Output.Discovery, "discovery-out", cfg.Output.Discovery, "where the discovery filters are recorded, empty to skip")
+	if err := parse(fs, args, cfg); err != nil {
+		return err
+	}
+
+	client := helper.NewClient(cfg.HTTP.ClientOptions())
+
+	productIDs, discovery, err := gatherIDs(interruptContext(), client, cfg)
+	reportSkipped(client)
+	if err != nil && len(productIDs) == 0 {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error gathering product IDs:", err)
+	}
+
+	if cfg.Output.Discovery != "" {
+		if err := export.WriteDiscovery(discovery, cfg.Output.Discovery); err != nil {
+			fmt.Fprintln(os.Stderr, "Error recording discovery filters:", err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	for _, id := range productIDs {
+		fmt.Fprintln(w, id)
+	}
+	return nil
+}
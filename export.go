@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/nahidhasan98/crawling/export"
+)
+
+// runExport converts a JSON dump written by the json format into other formats.
+func runExport(args []string) error {
+	fs, cfg, err := newCommand("export", "[flags]", args)
+	if err != nil {
+		return err
+	}
+	input := fs.String("in", cfg.Output.JSONPath, "JSON dump to convert")
+	addOutputFlags(fs, &cfg.Output)
+	if err := parse(fs, args, cfg); err != nil {
+		return err
+	}
+
+	products, err := export.ReadFromFile(*input)
+	if err != nil {
+		return err
+	}
+
+	return export.Export(products, cfg.Output)
+}
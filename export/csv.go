@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// WriteCSV writes one row per product with the same basic columns as the "Basic" spreadsheet sheet.
+// Size tables and review details are left out as they do not fit a flat table.
+func WriteCSV(products []model.Product, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+
+	err = w.Write([]string{
+		"ID", "Model", "URL", "Breadcrumb", "Category", "Name", "Price", "OriginalPrice", "DiscountPercent", "TaxIncluded", "Currency", "ImageURL", "AvailableSize", "SenseOfSize", "SizeFitScore", "SizeFit",
+		"DescriptionTitle", "DescriptionGeneral", "DescriptionItemization", "SpecialFunction",
+		"Rating", "NumberOfReviews", "RecommendedRate", "SenseOfFitting", "AppropriationOfLength", "QualityOfMaterial", "Comfort",
+		"AverageRating", "ReviewCount", "RecommendPercent", "Stars1", "Stars2", "Stars3", "Stars4", "Stars5",
+		"FitScore", "LengthScore", "QualityScore", "ComfortScore", "KWs", "SearchQueries", "Variants",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		r := p.Review
+		fitScore, fitLabel := "", ""
+		if p.Fit != nil {
+			fitScore, fitLabel = strconv.FormatFloat(p.Fit.Score, 'f', -1, 64), p.Fit.Label
+		}
+		err = w.Write([]string{
+			p.ID,
+			p.Model,
+			p.URL,
+			p.Breadcrumb,
+			p.Category,
+			p.Name,
+			formatAmount(p.Price, p.Price.Current),
+			formatAmount(p.Price, p.Price.Original),
+			strconv.Itoa(p.Price.DiscountPercent),
+			strconv.FormatBool(p.Price.TaxIncluded),
+			p.Price.Currency,
+			prepareImageURL(p.ImageURL),
+			prepareAvailableSize(p.AvailableSize),
+			p.SenseOfSize,
+			fitScore,
+			fitLabel,
+			p.Description.Title,
+			p.Description.General,
+			p.Description.Itemization,
+			p.SpecialFunction,
+			p.Review.Rating,
+			p.Review.NumberOfReviews,
+			p.Review.RecommendedRate,
+			p.Review.SenseOfFitting,
+			p.Review.AppropriationOfLength,
+			p.Review.QualityOfMaterial,
+			p.Review.Comfort,
+			formatScore(r.Average),
+			strconv.Itoa(r.Count),
+			formatScore(r.RecommendPercent),
+			strconv.Itoa(r.Histogram[0]),
+			strconv.Itoa(r.Histogram[1]),
+			strconv.Itoa(r.Histogram[2]),
+			strconv.Itoa(r.Histogram[3]),
+			strconv.Itoa(r.Histogram[4]),
+			formatScore(r.FitScore),
+			formatScore(r.LengthScore),
+			formatScore(r.QualityScore),
+			formatScore(r.ComfortScore),
+			prepareKWs(p.KWs),
+			prepareKWs(p.SearchQueries),
+			prepareKWs(p.Variants),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Data written to", filename)
+	return nil
+}
+
+// formatAmount writes amount, in minor units of the price's currency, as a plain
+// decimal number in major units, empty when the product has no price.
+func formatAmount(price model.Price, amount int64) string {
+	if price.Current == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(price.Major(amount), 'f', model.MinorUnitDigits(price.Currency), 64)
+}
+
+// formatScore writes an average or percentage, empty when it is unknown.
+func formatScore(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// Export writes products in every format selected by out, carrying on after a failed format.
+// The returned error names the formats that could not be written.
+func Export(products []model.Product, out config.Output) error {
+	var failed []string
+
+	if len(out.Fit) > 0 {
+		products = FilterFit(products, out.Fit)
+		fmt.Fprintln(os.Stderr, len(products), "products match the size fit", strings.Join(out.Fit, ", "))
+	}
+
+	for _, format := range out.Formats {
+		var err error
+
+		switch strings.TrimSpace(format) {
+		case "":
+			continue
+		case "json":
+			fmt.Fprintln(os.Stderr, "Writting data to file...")
+			err = WriteToFile(products, out.JSONPath)
+		case "xlsx":
+			fmt.Fprintln(os.Stderr, "Exporting data to Spreadsheet...")
+			err = Spreadsheet(products, out.Template, out.XLSXPath)
+		case "csv":
+			fmt.Fprintln(os.Stderr, "Exporting data to CSV...")
+			err = WriteCSV(products, out.CSVPath)
+		default:
+			err = fmt.Errorf("unknown format")
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error exporting to", format, ":", err)
+			failed = append(failed, format)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("export failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// FilterFit returns the products whose size fit has one of labels. Products
+// without a fit bar never match.
+func FilterFit(products []model.Product, labels []string) []model.Product {
+	var matched []model.Product
+	for _, p := range products {
+		if p.Fit != nil && slices.Contains(labels, p.Fit.Label) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
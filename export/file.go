@@ -8,11 +8,9 @@ import (
 	"github.com/nahidhasan98/crawling/model"
 )
 
-// WriteToFile serializes a slice of Product structs to JSON format and writes it to a file.
+// WriteToFile serializes a slice of Product structs to JSON format and writes it to filename.
 // The function returns an error if any file operation or JSON marshaling fails.
-func WriteToFile(product []model.Product) error {
-	filename := "product.txt"
-
+func WriteToFile(product []model.Product, filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -31,6 +29,38 @@ func WriteToFile(product []model.Product) error {
 		return err
 	}
 
-	fmt.Println("Data written to", filename)
+	fmt.Fprintln(os.Stderr, "Data written to", filename)
+	return nil
+}
+
+// ReadFromFile loads a slice of Product structs from a JSON file written by WriteToFile.
+func ReadFromFile(filename string) ([]model.Product, error) {
+	jsonData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []model.Product
+	err = json.Unmarshal(jsonData, &products)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return products, nil
+}
+
+// WriteDiscovery writes the filters and counts of a discovery run as JSON to filename.
+func WriteDiscovery(discovery model.Discovery, filename string) error {
+	jsonData, err := json.MarshalIndent(discovery, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, jsonData, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Discovery filters written to", filename)
 	return nil
 }
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/nahidhasan98/crawling/helper"
 	"github.com/nahidhasan98/crawling/model"
@@ -36,15 +36,14 @@ func createNewFileFromTemplate(src, dst string) error {
 }
 
 // writeReviewDetails writes the review details of a product to an Excel sheet.
-// It takes a slice of ReviewDetails and a serial number as parameters.
+// It takes the workbook path, a slice of ReviewDetails and a serial number as parameters.
 // The function returns the top-left and bottom-right cell references of the written data.
-func writeReviewDetails(reviewDetails []model.ReviewDetails, serial int) (string, string) {
-	filePath := "product.xlsx"
+func writeReviewDetails(filePath string, reviewDetails []model.ReviewDetails, serial int) (string, string) {
 	f, err := excelize.OpenFile(filePath)
 	helper.ErrorCheck(err)
 	defer func() {
 		if err := f.Close(); err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 		}
 	}()
 	reviewSheet := "Review"
@@ -62,6 +61,8 @@ func writeReviewDetails(reviewDetails []model.ReviewDetails, serial int) (string
 		f.SetCellValue(reviewSheet, "D"+strconv.Itoa(nextRow), v.Title)
 		f.SetCellValue(reviewSheet, "E"+strconv.Itoa(nextRow), v.Description)
 		f.SetCellValue(reviewSheet, "F"+strconv.Itoa(nextRow), v.ReviewerID)
+		err = writeReviewExtras(f, reviewSheet, strconv.Itoa(nextRow), v)
+		helper.ErrorCheck(err)
 		nextRow++
 	}
 
@@ -87,20 +88,115 @@ func writeReviewDetails(reviewDetails []model.ReviewDetails, serial int) (string
 	err = f.Save()
 	helper.ErrorCheck(err)
 
-	bottomRight = fmt.Sprintf("F%d", nextRow-1)
+	bottomRight = fmt.Sprintf("%s%d", extraReviewColumns[len(extraReviewColumns)-1].col, nextRow-1)
 	return topLeft, bottomRight
 }
 
-// writeTaleOfSize writes the TaleOfSize details of a product to an Excel sheet.
-// It takes a SizeTale struct and a serial number as parameters.
+// extraReviewColumns are the Review sheet columns appended after the ones of the template (A to F).
+var extraReviewColumns = []struct{ col, title string }{
+	{"G", "Review ID"},
+	{"H", "Score"},
+	{"I", "Submitted"},
+	{"J", "Helpful"},
+	{"K", "Not Helpful"},
+	{"L", "Fit"},
+	{"M", "Length"},
+	{"N", "Quality"},
+	{"O", "Comfort"},
+	{"P", "Size Purchased"},
+	{"Q", "Height"},
+	{"R", "Usual Size"},
+	{"S", "Photos"},
+	{"T", "Brand Response"},
+}
+
+// writeReviewExtras writes the extraReviewColumns of one review. Scores that were
+// not given are left blank.
+func writeReviewExtras(f *excelize.File, sheet, row string, v model.ReviewDetails) error {
+	f.SetCellValue(sheet, "G"+row, v.ID)
+	if v.Score > 0 {
+		f.SetCellValue(sheet, "H"+row, v.Score)
+	}
+	if v.Submitted != nil {
+		dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+		if err != nil {
+			return err
+		}
+		f.SetCellValue(sheet, "I"+row, *v.Submitted)
+		f.SetCellStyle(sheet, "I"+row, "I"+row, dateStyle)
+	}
+	f.SetCellValue(sheet, "J"+row, v.HelpfulVotes)
+	f.SetCellValue(sheet, "K"+row, v.UnhelpfulVotes)
+
+	scores := []struct {
+		col   string
+		value int
+	}{{"L", v.Fit}, {"M", v.Length}, {"N", v.Quality}, {"O", v.Comfort}}
+	for _, score := range scores {
+		if score.value > 0 {
+			f.SetCellValue(sheet, score.col+row, score.value)
+		}
+	}
+
+	f.SetCellValue(sheet, "P"+row, v.SizePurchased)
+	f.SetCellValue(sheet, "Q"+row, v.Height)
+	f.SetCellValue(sheet, "R"+row, v.UsualSize)
+	f.SetCellValue(sheet, "S"+row, prepareImageURL(v.Photos))
+	f.SetCellValue(sheet, "T"+row, v.BrandResponse)
+
+	return nil
+}
+
+// writeReviewHeaders adds the headers of extraReviewColumns to the second header row
+// of the Review sheet and widens the "Review Details" group above them.
+func writeReviewHeaders(filePath string) error {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reviewSheet := "Review"
+	style, err := f.GetCellStyle(reviewSheet, "B2")
+	if err != nil {
+		return err
+	}
+
+	for _, c := range extraReviewColumns {
+		f.SetCellValue(reviewSheet, c.col+"2", c.title)
+		f.SetCellStyle(reviewSheet, c.col+"2", c.col+"2", style)
+	}
+
+	last := extraReviewColumns[len(extraReviewColumns)-1].col
+	err = f.UnmergeCell(reviewSheet, "B1", "F1")
+	if err != nil {
+		return err
+	}
+	err = f.MergeCell(reviewSheet, "B1", last+"1")
+	if err != nil {
+		return err
+	}
+	groupStyle, err := f.GetCellStyle(reviewSheet, "B1")
+	if err != nil {
+		return err
+	}
+	f.SetCellStyle(reviewSheet, "B1", last+"1", groupStyle)
+
+	return f.Save()
+}
+
+// writeTaleOfSize writes every size chart of a product to an Excel sheet.
+// It takes the workbook path, a SizeChart struct and a serial number as parameters.
+// Charts are stacked below their name with the sizes across: the header rows run
+// down the first columns and every body row fills a column of its own. Measurements
+// are written as numbers showing their unit.
 // The function returns the top-left and bottom-right cell references of the written data.
-func writeTaleOfSize(taleOfSize model.SizeTale, serial int) (string, string) {
-	filePath := "product.xlsx"
+func writeTaleOfSize(filePath string, taleOfSize model.SizeChart, serial int) (string, string) {
 	f, err := excelize.OpenFile(filePath)
 	helper.ErrorCheck(err)
 	defer func() {
 		if err := f.Close(); err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 		}
 	}()
 	sizeSheet := "TaleOfSize"
@@ -110,51 +206,45 @@ func writeTaleOfSize(taleOfSize model.SizeTale, serial int) (string, string) {
 
 	nextRow := len(rows) + 1
 	startRow := nextRow
+	lastCol := 2
 
 	f.SetCellValue(sizeSheet, "A"+strconv.Itoa(nextRow), serial+1)
 
-	header := taleOfSize.SizeChart["0"].Header["0"]
+	for _, chart := range taleOfSize.Charts {
+		if len(taleOfSize.Charts) > 1 {
+			f.SetCellValue(sizeSheet, "B"+strconv.Itoa(nextRow), chart.Name)
+			nextRow++
+		}
 
-	keys := make([]string, 0, len(header))
-	for key := range header {
-		keys = append(keys, key)
-	}
-	// Sort the keys
-	sort.Strings(keys)
-	// Iterate over the sorted keys and print the key-value pairs
-	for _, key := range keys {
-		// fmt.Printf("%s: %s\n", key, header[key])
-		f.SetCellValue(sizeSheet, "B"+strconv.Itoa(nextRow), header[key].Value)
-		nextRow++
-	}
+		height := 0
+		col := 2
+		for _, header := range chart.Header {
+			for i, label := range header {
+				cell, err := excelize.CoordinatesToCellName(col, nextRow+i)
+				helper.ErrorCheck(err)
+				f.SetCellValue(sizeSheet, cell, label)
+			}
+			height = max(height, len(header))
+			col++
+		}
 
-	body := taleOfSize.SizeChart["0"].Body
-	col := "C"
-	keys2 := make([]string, 0, len(body))
-	for key2 := range body {
-		keys2 = append(keys2, key2)
+		for _, row := range chart.Rows {
+			for i, sizeCell := range row {
+				cell, err := excelize.CoordinatesToCellName(col, nextRow+i)
+				helper.ErrorCheck(err)
+				err = writeSizeCell(f, sizeSheet, cell, sizeCell)
+				helper.ErrorCheck(err)
+			}
+			height = max(height, len(row))
+			col++
+		}
+
+		lastCol = max(lastCol, col-1)
+		nextRow += height
 	}
-	// Sort the keys
-	sort.Strings(keys2)
-	// Iterate over the sorted keys and print the key-value pairs
-	for _, key2 := range keys2 {
-		// fmt.Printf("%s: %s\n", key, body[key])
-		nextRow = startRow
 
-		keys3 := make([]string, 0, len(body[key2]))
-		for key3 := range body[key2] {
-			keys3 = append(keys3, key3)
-		}
-		// Sort the keys
-		sort.Strings(keys3)
-		// Iterate over the sorted keys and print the key-value pairs
-		for _, key3 := range keys3 {
-			f.SetCellValue(sizeSheet, fmt.Sprintf("%s%d", col, nextRow), body[key2][key3].Value)
-			nextRow++
-		}
-		colRune := []rune(col)[0]
-		colRune++
-		col = string(colRune)
+	if nextRow == startRow {
+		nextRow++
 	}
 
 	if startRow > 0 && startRow < nextRow-1 {
@@ -178,15 +268,204 @@ func writeTaleOfSize(taleOfSize model.SizeTale, serial int) (string, string) {
 	err = f.Save()
 	helper.ErrorCheck(err)
 
-	colRune := []rune(col)[0]
-	colRune--
-	col = string(colRune)
-
 	topLeft := fmt.Sprintf("A%d", startRow)
-	bottomRight := fmt.Sprintf("%s%d", col, nextRow-1)
+	bottomRight, err := excelize.CoordinatesToCellName(lastCol, nextRow-1)
+	helper.ErrorCheck(err)
 	return topLeft, bottomRight
 }
 
+// writeSizeCell writes a size table cell, as a number formatted with its unit when
+// it holds a measurement and as text otherwise.
+func writeSizeCell(f *excelize.File, sheet, cell string, sizeCell model.SizeCell) error {
+	if sizeCell.Value == 0 {
+		return f.SetCellValue(sheet, cell, sizeCell.Text)
+	}
+
+	err := f.SetCellValue(sheet, cell, sizeCell.Value)
+	if err != nil || sizeCell.Unit == "" {
+		return err
+	}
+
+	format := fmt.Sprintf(`General"%s"`, strings.ReplaceAll(sizeCell.Unit, `"`, `\"`))
+	style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return err
+	}
+	return f.SetCellStyle(sheet, cell, cell, style)
+}
+
+// writeStock writes the availability of every size to the "Stock" sheet, one row per
+// size, adding the sheet when the template has none.
+func writeStock(filePath string, products []model.Product) error {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stockSheet := "Stock"
+	index, err := f.GetSheetIndex(stockSheet)
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		_, err = f.NewSheet(stockSheet)
+		if err != nil {
+			return err
+		}
+		err = f.SetSheetRow(stockSheet, "A1", &[]string{"No.", "Product ID", "Size", "SKU", "In Stock", "Low Stock", "Quantity"})
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := f.GetRows(stockSheet)
+	if err != nil {
+		return err
+	}
+	nextRow := len(rows) + 1
+
+	for i, p := range products {
+		for _, size := range p.Stock {
+			row := strconv.Itoa(nextRow)
+			f.SetCellValue(stockSheet, "A"+row, i+1)
+			f.SetCellValue(stockSheet, "B"+row, p.ID)
+			f.SetCellValue(stockSheet, "C"+row, size.Label)
+			f.SetCellValue(stockSheet, "D"+row, size.SKU)
+			f.SetCellValue(stockSheet, "E"+row, size.InStock)
+			f.SetCellValue(stockSheet, "F"+row, size.LowStock)
+			if size.Quantity != nil {
+				f.SetCellValue(stockSheet, "G"+row, *size.Quantity)
+			}
+			nextRow++
+		}
+	}
+
+	return f.Save()
+}
+
+// extraBasicColumns are the Basic sheet columns appended after the ones of the template (A to AB).
+var extraBasicColumns = []struct{ col, title string }{
+	{"AC", "Search Query"},
+	{"AD", "Model"},
+	{"AE", "Color Variants"},
+	{"AF", "Original Price"},
+	{"AG", "Discount"},
+	{"AH", "Tax Included"},
+	{"AI", "Currency"},
+	{"AJ", "Average Rating"},
+	{"AK", "Review Count"},
+	{"AL", "Recommend %"},
+	{"AM", "1 Star"},
+	{"AN", "2 Stars"},
+	{"AO", "3 Stars"},
+	{"AP", "4 Stars"},
+	{"AQ", "5 Stars"},
+	{"AR", "Fit Score"},
+	{"AS", "Length Score"},
+	{"AT", "Quality Score"},
+	{"AU", "Comfort Score"},
+	{"AV", "Size Fit Score"},
+	{"AW", "Size Fit"},
+}
+
+// writeExtraHeaders adds the headers of extraBasicColumns to the Basic sheet,
+// spanning both header rows and styled like the template's own headers.
+func writeExtraHeaders(filePath string) error {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	basicSheet := "Basic"
+	style, err := f.GetCellStyle(basicSheet, "A1")
+	if err != nil {
+		return err
+	}
+
+	for _, c := range extraBasicColumns {
+		f.SetCellValue(basicSheet, c.col+"1", c.title)
+		err = f.MergeCell(basicSheet, c.col+"1", c.col+"2")
+		if err != nil {
+			return err
+		}
+		f.SetCellStyle(basicSheet, c.col+"1", c.col+"2", style)
+	}
+
+	return f.Save()
+}
+
+// priceFormat returns the number format showing amounts of currency, e.g. ¥16,500.
+func priceFormat(currency string) string {
+	format := "#,##0"
+	if digits := model.MinorUnitDigits(currency); digits > 0 {
+		format += "." + strings.Repeat("0", digits)
+	}
+
+	if currency == "JPY" {
+		return "[$¥-411]" + format
+	}
+	return format + ` "` + currency + `"`
+}
+
+// writePrice writes the prices of a Basic sheet row as numbers formatted in their
+// currency. Products without a price are left blank.
+func writePrice(f *excelize.File, sheet, row string, price model.Price) error {
+	if price.Current == 0 {
+		return nil
+	}
+
+	format := priceFormat(price.Currency)
+	priceStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return err
+	}
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 9})
+	if err != nil {
+		return err
+	}
+
+	f.SetCellValue(sheet, "F"+row, price.Major(price.Current))
+	f.SetCellStyle(sheet, "F"+row, "F"+row, priceStyle)
+	f.SetCellValue(sheet, "AF"+row, price.Major(price.Original))
+	f.SetCellStyle(sheet, "AF"+row, "AF"+row, priceStyle)
+	f.SetCellValue(sheet, "AG"+row, float64(price.DiscountPercent)/100)
+	f.SetCellStyle(sheet, "AG"+row, "AG"+row, percentStyle)
+	f.SetCellValue(sheet, "AH"+row, price.TaxIncluded)
+	f.SetCellValue(sheet, "AI"+row, price.Currency)
+
+	return nil
+}
+
+// writeReviewSummary writes the review numbers of a Basic sheet row as numeric cells
+// so products can be sorted and charted by them. Averages that are unknown are left blank.
+func writeReviewSummary(f *excelize.File, sheet, row string, review model.Review) {
+	averages := []struct {
+		col   string
+		value float64
+	}{
+		{"AJ", review.Average},
+		{"AR", review.FitScore},
+		{"AS", review.LengthScore},
+		{"AT", review.QualityScore},
+		{"AU", review.ComfortScore},
+	}
+	for _, a := range averages {
+		if a.value > 0 {
+			f.SetCellValue(sheet, a.col+row, a.value)
+		}
+	}
+
+	f.SetCellValue(sheet, "AK"+row, review.Count)
+	if review.RecommendPercent > 0 {
+		f.SetCellValue(sheet, "AL"+row, review.RecommendPercent)
+	}
+	for i, col := range []string{"AM", "AN", "AO", "AP", "AQ"} {
+		f.SetCellValue(sheet, col+row, review.Histogram[i])
+	}
+}
+
 // prepareImageURL formats a slice of image URLs into a numbered list as a string.
 func prepareImageURL(imageURLs []string) string {
 	res := ""
@@ -229,21 +508,27 @@ func prepareKWs(kws []string) string {
 }
 
 // Spreadsheet creates an Excel file containing product details using data from a slice of Product structs.
-// It uses a template Excel file, writes product data to the file, and saves it as "product.xlsx".
+// It copies the template Excel file to filePath and writes the product data into the copy.
 // The function returns an error if any operation fails during file creation or data writing.
-func Spreadsheet(products []model.Product) error {
-	src := "./template/template.xlsx"
-	dst := "./product.xlsx"
-	filePath := "product.xlsx"
+func Spreadsheet(products []model.Product, template, filePath string) error {
+	err := createNewFileFromTemplate(template, filePath)
+	if err != nil {
+		return err
+	}
+
+	err = writeExtraHeaders(filePath)
+	if err != nil {
+		return err
+	}
 
-	err := createNewFileFromTemplate(src, dst)
+	err = writeReviewHeaders(filePath)
 	if err != nil {
 		return err
 	}
 
 	for i := 0; i < len(products); i++ {
-		topLeft, bottomRight := writeTaleOfSize(products[i].TaleOfSize, i)
-		topLeft2, bottomRight2 := writeReviewDetails(products[i].Review.Details, i)
+		topLeft, bottomRight := writeTaleOfSize(filePath, products[i].TaleOfSize, i)
+		topLeft2, bottomRight2 := writeReviewDetails(filePath, products[i].Review.Details, i)
 
 		f, err := excelize.OpenFile(filePath)
 		if err != nil {
@@ -262,7 +547,10 @@ func Spreadsheet(products []model.Product) error {
 		f.SetCellValue(basicSheet, "C"+strconv.Itoa(nextRow), products[i].Breadcrumb)
 		f.SetCellValue(basicSheet, "D"+strconv.Itoa(nextRow), products[i].Category)
 		f.SetCellValue(basicSheet, "E"+strconv.Itoa(nextRow), products[i].Name)
-		f.SetCellValue(basicSheet, "F"+strconv.Itoa(nextRow), fmt.Sprintf("%s %s", products[i].Currency, products[i].Price))
+		err = writePrice(f, basicSheet, strconv.Itoa(nextRow), products[i].Price)
+		if err != nil {
+			return err
+		}
 
 		imageURL := prepareImageURL(products[i].ImageURL)
 		f.SetCellValue(basicSheet, "G"+strconv.Itoa(nextRow), imageURL)
@@ -312,6 +600,16 @@ func Spreadsheet(products []model.Product) error {
 		kws := prepareKWs(products[i].KWs)
 		f.SetCellValue(basicSheet, "W"+strconv.Itoa(nextRow), kws)
 
+		searchQueries := prepareKWs(products[i].SearchQueries)
+		f.SetCellValue(basicSheet, "AC"+strconv.Itoa(nextRow), searchQueries)
+		f.SetCellValue(basicSheet, "AD"+strconv.Itoa(nextRow), products[i].Model)
+		f.SetCellValue(basicSheet, "AE"+strconv.Itoa(nextRow), prepareKWs(products[i].Variants))
+		writeReviewSummary(f, basicSheet, strconv.Itoa(nextRow), products[i].Review)
+		if fit := products[i].Fit; fit != nil {
+			f.SetCellValue(basicSheet, "AV"+strconv.Itoa(nextRow), fit.Score)
+			f.SetCellValue(basicSheet, "AW"+strconv.Itoa(nextRow), fit.Label)
+		}
+
 		err = f.Save()
 		if err != nil {
 			return err
@@ -323,6 +621,11 @@ func Spreadsheet(products []model.Product) error {
 		}
 	}
 
-	fmt.Println("Data exported to", filePath, "successfully.")
+	err = writeStock(filePath, products)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Data exported to", filePath, "successfully.")
 	return nil
 }
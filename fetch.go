@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nahidhasan98/crawling/export"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/product"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// runFetch fetches the details of the IDs given as arguments or in an ID file and exports them.
+func runFetch(args []string) error {
+	fs, cfg, err := newCommand("fetch", "[flags] [ID...]", args)
+	if err != nil {
+		return err
+	}
+	addClientFlags(fs, &cfg.HTTP)
+	idFile := fs.String("ids", "", "file with one product ID per line")
+	fs.IntVar(&cfg.Crawl.Concurrency, "concurrency", cfg.Crawl.Concurrency, "number of products fetched in parallel")
+	fs.BoolVar(&cfg.Crawl.ExpandVariants, "expand-variants", cfg.Crawl.ExpandVariants, "also fetch every color variant of the given products")
+	addRulesFlag(fs, &cfg.Site)
+	addReviewFlags(fs, &cfg.Crawl.Reviews)
+	addOutputFlags(fs, &cfg.Output)
+	if err := parse(fs, args, cfg); err != nil {
+		return err
+	}
+
+	r, err := rules.Load(cfg.Site.Rules)
+	if err != nil {
+		return err
+	}
+
+	productIDs := fs.Args()
+	if *idFile != "" {
+		fileIDs, err := readIDs(*idFile)
+		if err != nil {
+			return err
+		}
+		productIDs = append(productIDs, fileIDs...)
+	}
+	if len(productIDs) == 0 {
+		return fmt.Errorf("no product IDs given")
+	}
+
+	client := helper.NewClient(cfg.HTTP.ClientOptions())
+
+	ctx := interruptContext()
+
+	results, errs := product.FetchAll(ctx, client, cfg.Site, r, cfg.Crawl.Reviews, productIDs, cfg.Crawl.Concurrency, nil)
+	products := collect(productIDs, results, errs)
+
+	if cfg.Crawl.ExpandVariants {
+		variantIDs := product.VariantIDs(results, productIDs)
+		if len(variantIDs) > 0 {
+			fmt.Fprintln(os.Stderr, "Expanding to", len(variantIDs), "more color variants")
+			results, errs = product.FetchAll(ctx, client, cfg.Site, r, cfg.Crawl.Reviews, variantIDs, cfg.Crawl.Concurrency, nil)
+			products = append(products, collect(variantIDs, results, errs)...)
+		}
+		products = product.GroupVariants(products)
+	}
+
+	reportSkipped(client)
+
+	return export.Export(products, cfg.Output)
+}
@@ -1,17 +1,117 @@
 package helper
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
 
 // Recovery function for recovering from panic
 func ErrorRecovery() {
 	if r := recover(); r != nil {
-		fmt.Println("recovered from ", r)
+		fmt.Fprintln(os.Stderr, "recovered from ", r)
 	}
 }
 
 // Check function for checking error
 func ErrorCheck(err error) {
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 	}
 }
+
+// ErrorKind classifies why a request failed.
+type ErrorKind int
+
+const (
+	KindNetwork ErrorKind = iota
+	KindDNS
+	KindTimeout
+	KindClient
+	KindServer
+	KindDecode
+	KindDisallowed
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindDNS:
+		return "dns"
+	case KindTimeout:
+		return "timeout"
+	case KindClient:
+		return "client error"
+	case KindServer:
+		return "server error"
+	case KindDecode:
+		return "decode"
+	case KindDisallowed:
+		return "robots"
+	default:
+		return "network"
+	}
+}
+
+// FetchError describes a failed request together with its classification.
+type FetchError struct {
+	Kind       ErrorKind
+	URL        string
+	StatusCode int
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s returned status %d", e.Kind, e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError wraps a transport error into a FetchError of the matching kind.
+func classifyError(apiURL string, err error) *FetchError {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	kind := KindNetwork
+	if errors.As(err, &dnsErr) {
+		kind = KindDNS
+	} else if errors.As(err, &netErr) && netErr.Timeout() {
+		kind = KindTimeout
+	}
+
+	return &FetchError{Kind: kind, URL: apiURL, Err: err}
+}
+
+// statusError returns a FetchError for a 4xx/5xx status, or nil for anything else.
+func statusError(apiURL string, status int) *FetchError {
+	switch {
+	case status >= 500:
+		return &FetchError{Kind: KindServer, URL: apiURL, StatusCode: status}
+	case status >= 400:
+		return &FetchError{Kind: KindClient, URL: apiURL, StatusCode: status}
+	}
+	return nil
+}
+
+// DecodeError wraps a failure to parse the body fetched from apiURL.
+func DecodeError(apiURL string, err error) error {
+	return &FetchError{Kind: KindDecode, URL: apiURL, Err: err}
+}
+
+// IsNotFound reports whether err is a FetchError for a 404 response.
+func IsNotFound(err error) bool {
+	var fetchErr *FetchError
+	return errors.As(err, &fetchErr) && fetchErr.StatusCode == http.StatusNotFound
+}
+
+// IsDisallowed reports whether err is a FetchError for a URL refused by robots.txt.
+func IsDisallowed(err error) bool {
+	var fetchErr *FetchError
+	return errors.As(err, &fetchErr) && fetchErr.Kind == KindDisallowed
+}
@@ -1,17 +1,176 @@
 package helper
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
 
-func GETRequest(apiURL string) *http.Response {
-	// for panic() error Recovery
-	defer ErrorRecovery()
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	ConnectTimeout      time.Duration     // dialing and TLS handshake
+	ReadTimeout         time.Duration     // waiting for the response headers once the request is sent
+	Timeout             time.Duration     // a whole attempt, including reading the body
+	UserAgent           string            // also provides the agent name matched against robots.txt
+	AcceptLanguage      string            // sent as Accept-Language unless empty
+	Headers             map[string]string // extra headers sent with every request
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int // zero means no limit
+	Retry               RetryPolicy
+	Limiter             *RateLimiter // nil uses DefaultRateLimiter
+	IgnoreRobots        bool         // fetch URLs even when robots.txt disallows them
+}
+
+// DefaultClientOptions returns the options used by the crawler unless overridden.
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		ConnectTimeout:      10 * time.Second,
+		ReadTimeout:         30 * time.Second,
+		Timeout:             60 * time.Second,
+		UserAgent:           "crawling/1.0 (+https://github.com/nahidhasan98/crawling)",
+		AcceptLanguage:      "ja-JP,ja;q=0.9",
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 16,
+		Retry:               DefaultRetryPolicy(),
+	}
+}
+
+// Client is the HTTP client shared by the whole crawl. It reuses connections,
+// applies timeouts and headers, throttles per host, consults robots.txt and
+// retries failed requests.
+type Client struct {
+	options ClientOptions
+	http    *http.Client
+	Retry   RetryPolicy
+	Limiter *RateLimiter
+	Robots  *RobotsChecker
+}
+
+// NewClient builds a Client from options. It is safe for concurrent use.
+func NewClient(options ClientOptions) *Client {
+	limiter := options.Limiter
+	if limiter == nil {
+		limiter = DefaultRateLimiter()
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   options.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   options.ConnectTimeout,
+		ResponseHeaderTimeout: options.ReadTimeout,
+		MaxIdleConns:          options.MaxIdleConns,
+		MaxIdleConnsPerHost:   options.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       options.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+
+	c := &Client{
+		options: options,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   options.Timeout,
+		},
+		Retry:   options.Retry,
+		Limiter: limiter,
+	}
+	c.Robots = newRobotsChecker(robotsAgent(options.UserAgent), c)
+	c.Robots.Ignore = options.IgnoreRobots
+
+	return c
+}
+
+// robotsAgent returns the product token of a User-Agent, e.g. "crawling" for "crawling/1.0 (...)".
+func robotsAgent(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return token
+}
+
+// GETRequest fetches apiURL and returns the response body and status code.
+// Transport failures and 4xx/5xx responses are returned as a *FetchError;
+// the body is still returned for error statuses so callers can inspect it.
+// URLs refused by robots.txt are not requested at all and fail with KindDisallowed.
+// Failed attempts are retried according to Retry and every attempt is throttled by Limiter.
+// Cancelling ctx aborts the request as well as any pending wait.
+func (c *Client) GETRequest(ctx context.Context, apiURL string) ([]byte, int, error) {
+	if err := c.Robots.Check(ctx, apiURL); err != nil {
+		return nil, 0, err
+	}
+
+	return c.get(ctx, apiURL)
+}
+
+// get fetches apiURL with retries but without consulting robots.txt.
+func (c *Client) get(ctx context.Context, apiURL string) ([]byte, int, error) {
+	attempts := max(c.Retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		responseBody, status, header, err := c.doRequest(ctx, apiURL)
+		if err == nil || attempt >= attempts || !c.Retry.retryable(err) || ctx.Err() != nil {
+			return responseBody, status, err
+		}
+
+		wait := c.Retry.delay(attempt, header)
+		fmt.Fprintf(os.Stderr, "Attempt %d/%d failed: %v; retrying in %s\n", attempt, attempts, err, wait.Round(time.Millisecond))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return responseBody, status, err
+		case <-timer.C:
+		}
+	}
+}
+
+// doRequest performs a single GET request for apiURL once Limiter lets it go out.
+// The wait does not count against the request timeout.
+func (c *Client) doRequest(ctx context.Context, apiURL string) ([]byte, int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, 0, nil, classifyError(apiURL, err)
+	}
+
+	err = c.Limiter.Wait(ctx, req.URL.Hostname())
+	if err != nil {
+		return nil, 0, nil, classifyError(apiURL, err)
+	}
+
+	if c.options.UserAgent != "" {
+		req.Header.Set("User-Agent", c.options.UserAgent)
+	}
+	if c.options.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", c.options.AcceptLanguage)
+	}
+	for key, value := range c.options.Headers {
+		req.Header.Set(key, value)
+	}
+
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, nil, classifyError(apiURL, err)
+	}
+	defer response.Body.Close()
 
-	req, err := http.NewRequest("GET", apiURL, nil)
-	ErrorCheck(err)
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, response.StatusCode, response.Header, classifyError(apiURL, err)
+	}
 
-	client := &http.Client{}
-	response, err := client.Do(req)
-	ErrorCheck(err)
+	if fetchErr := statusError(apiURL, response.StatusCode); fetchErr != nil {
+		return responseBody, response.StatusCode, response.Header, fetchErr
+	}
 
-	return response
+	return responseBody, response.StatusCode, response.Header, nil
 }
@@ -0,0 +1,137 @@
+package helper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HostLimit configures how fast requests may be sent to a single host.
+type HostLimit struct {
+	RequestsPerSecond float64       // sustained rate, zero or less disables the token bucket
+	Burst             int           // requests allowed back to back before the rate applies
+	MinDelay          time.Duration // minimum gap between two consecutive requests
+}
+
+// RateLimiter keeps a token bucket per host and blocks callers until their request may go out.
+type RateLimiter struct {
+	mu      sync.Mutex
+	def     HostLimit
+	limits  map[string]HostLimit
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	limit       HostLimit
+	tokens      float64
+	last        time.Time
+	nextAllowed time.Time
+}
+
+// NewRateLimiter returns a limiter applying limits to the listed hosts and def to any other host.
+func NewRateLimiter(def HostLimit, limits map[string]HostLimit) *RateLimiter {
+	l := &RateLimiter{
+		def:     def,
+		limits:  map[string]HostLimit{},
+		buckets: map[string]*bucket{},
+	}
+	for host, limit := range limits {
+		l.limits[host] = limit
+	}
+	return l
+}
+
+// SetLimit replaces the limit of host; requests already waiting keep their slot.
+func (l *RateLimiter) SetLimit(host string, limit HostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits[host] = limit
+	if b, ok := l.buckets[host]; ok {
+		b.limit = limit
+		b.tokens = min(b.tokens, float64(max(limit.Burst, 1)))
+	}
+}
+
+// Limit returns the limit applied to host.
+func (l *RateLimiter) Limit(host string) HostLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limitFor(host)
+}
+
+func (l *RateLimiter) limitFor(host string) HostLimit {
+	if limit, ok := l.limits[host]; ok {
+		return limit
+	}
+	return l.def
+}
+
+// Wait blocks until a request to host is allowed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	wait := l.reserve(host)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve books the next request slot for host and returns how long to wait for it.
+func (l *RateLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	b, ok := l.buckets[host]
+	if !ok {
+		limit := l.limitFor(host)
+		b = &bucket{limit: limit, tokens: float64(max(limit.Burst, 1)), last: now}
+		l.buckets[host] = b
+	}
+
+	ready := now
+	if b.limit.RequestsPerSecond > 0 {
+		burst := float64(max(b.limit.Burst, 1))
+		b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*b.limit.RequestsPerSecond)
+		b.last = now
+
+		// tokens may go negative: every waiting caller has already booked one
+		if b.tokens < 1 {
+			missing := (1 - b.tokens) / b.limit.RequestsPerSecond
+			ready = now.Add(time.Duration(missing * float64(time.Second)))
+		}
+		b.tokens--
+	}
+
+	if ready.Before(b.nextAllowed) {
+		ready = b.nextAllowed
+	}
+	b.nextAllowed = ready.Add(b.limit.MinDelay)
+
+	return ready.Sub(now)
+}
+
+// DefaultHostLimit applies to the hosts DefaultHostLimits does not list.
+var DefaultHostLimit = HostLimit{RequestsPerSecond: 2, Burst: 2, MinDelay: 100 * time.Millisecond}
+
+// DefaultHostLimits are conservative limits for the hosts the crawler visits.
+var DefaultHostLimits = map[string]HostLimit{
+	"shop.adidas.jp":               {RequestsPerSecond: 4, Burst: 4, MinDelay: 100 * time.Millisecond},
+	"adidasjp.ugc.bazaarvoice.com": {RequestsPerSecond: 2, Burst: 2, MinDelay: 200 * time.Millisecond},
+}
+
+// DefaultRateLimiter returns a limiter applying DefaultHostLimits and DefaultHostLimit.
+func DefaultRateLimiter() *RateLimiter {
+	return NewRateLimiter(DefaultHostLimit, DefaultHostLimits)
+}
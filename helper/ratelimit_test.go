@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit HostLimit
+		want  []time.Duration // waits of consecutive reservations made at once
+	}{
+		{
+			name:  "burst goes out at once, then one per token",
+			limit: HostLimit{RequestsPerSecond: 10, Burst: 2},
+			want:  []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:  "minimum delay without a token bucket",
+			limit: HostLimit{MinDelay: 50 * time.Millisecond},
+			want:  []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name:  "minimum delay spaces out a burst",
+			limit: HostLimit{RequestsPerSecond: 100, Burst: 5, MinDelay: 30 * time.Millisecond},
+			want:  []time.Duration{0, 30 * time.Millisecond, 60 * time.Millisecond},
+		},
+		{
+			name:  "no limit",
+			limit: HostLimit{},
+			want:  []time.Duration{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(HostLimit{}, map[string]HostLimit{"example.com": tt.limit})
+
+			for i, want := range tt.want {
+				got := l.reserve("example.com")
+				if got < want-5*time.Millisecond || got > want+5*time.Millisecond {
+					t.Errorf("reservation %d waits %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterHostsAreIndependent(t *testing.T) {
+	l := NewRateLimiter(HostLimit{MinDelay: time.Second}, nil)
+
+	if wait := l.reserve("a.example.com"); wait != 0 {
+		t.Errorf("first request to a waits %v", wait)
+	}
+	if wait := l.reserve("b.example.com"); wait != 0 {
+		t.Errorf("first request to b waits %v", wait)
+	}
+}
+
+func TestRateLimiterSetLimit(t *testing.T) {
+	l := NewRateLimiter(HostLimit{}, nil)
+	l.reserve("example.com")
+
+	l.SetLimit("example.com", HostLimit{MinDelay: time.Second})
+	if got := l.Limit("example.com").MinDelay; got != time.Second {
+		t.Errorf("Limit().MinDelay = %v, want 1s", got)
+	}
+
+	l.reserve("example.com")
+	if wait := l.reserve("example.com"); wait < 900*time.Millisecond {
+		t.Errorf("request after SetLimit waits %v, want about 1s", wait)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := NewRateLimiter(HostLimit{MinDelay: time.Hour}, nil)
+	l.reserve("example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, "example.com"); err != context.Canceled {
+		t.Errorf("Wait = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries failed requests.
+type RetryPolicy struct {
+	MaxAttempts     int           // total attempts including the first one
+	BaseDelay       time.Duration // delay before the first retry, doubled on every further retry
+	MaxDelay        time.Duration // upper bound for a single delay
+	Jitter          float64       // fraction (0..1) of the delay that is randomized
+	RetryableStatus []int         // status codes worth retrying
+	HonorRetryAfter bool          // wait for the server supplied Retry-After when present
+}
+
+// DefaultRetryPolicy returns the policy used by DefaultClientOptions.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    15 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		HonorRetryAfter: true,
+	}
+}
+
+// retryable reports whether a request that failed with err is worth another attempt.
+// DNS and decode failures are permanent, other transport errors are not.
+func (p RetryPolicy) retryable(err error) bool {
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		return false
+	}
+
+	switch fetchErr.Kind {
+	case KindNetwork, KindTimeout:
+		return true
+	case KindClient, KindServer:
+		return slices.Contains(p.RetryableStatus, fetchErr.StatusCode)
+	}
+	return false
+}
+
+// delay returns how long to wait before the given retry (1 for the first retry).
+func (p RetryPolicy) delay(retry int, header http.Header) time.Duration {
+	if p.HonorRetryAfter && header != nil {
+		if wait, ok := retryAfter(header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 && wait > p.MaxDelay {
+				return p.MaxDelay
+			}
+			return wait
+		}
+	}
+
+	wait := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || wait < p.MaxDelay); i++ {
+		wait *= 2
+	}
+	if p.MaxDelay > 0 && wait > p.MaxDelay {
+		wait = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		// spread the delay uniformly over [wait*(1-jitter), wait*(1+jitter)]
+		factor := 1 - p.Jitter + rand.Float64()*2*p.Jitter
+		wait = time.Duration(float64(wait) * factor)
+	}
+
+	return wait
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "5", 5 * time.Second, true},
+		{"zero seconds", "0", 0, true},
+		{"negative seconds", "-1", 0, false},
+		{"garbage", "soon", 0, false},
+		{"past date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	// an HTTP date has a resolution of one second
+	future := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	got, ok := retryAfter(future)
+	if !ok || got < 28*time.Second || got > 30*time.Second {
+		t.Errorf("retryAfter(%q) = %v, %v, want about 30s", future, got, ok)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay:       500 * time.Millisecond,
+		MaxDelay:        4 * time.Second,
+		HonorRetryAfter: true,
+	}
+	header := func(retryAfter string) http.Header {
+		return http.Header{"Retry-After": []string{retryAfter}}
+	}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		retry  int
+		header http.Header
+		want   time.Duration
+	}{
+		{"first retry waits the base delay", policy, 1, nil, 500 * time.Millisecond},
+		{"second retry doubles", policy, 2, nil, time.Second},
+		{"fourth retry reaches the cap", policy, 4, nil, 4 * time.Second},
+		{"later retries stay at the cap", policy, 10, nil, 4 * time.Second},
+		{"Retry-After is honored", policy, 1, header("2"), 2 * time.Second},
+		{"Retry-After is capped", policy, 1, header("60"), 4 * time.Second},
+		{"invalid Retry-After falls back to backoff", policy, 2, header("later"), time.Second},
+		{"Retry-After is ignored when not honored", RetryPolicy{BaseDelay: time.Second}, 1, header("30"), time.Second},
+		{"no cap", RetryPolicy{BaseDelay: time.Second}, 6, nil, 32 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.retry, tt.header); got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+
+	for range 100 {
+		got := policy.delay(1, nil)
+		if got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("delay(1) = %v, want within 800ms-1.2s", got)
+		}
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", &FetchError{Kind: KindNetwork}, true},
+		{"timeout", &FetchError{Kind: KindTimeout}, true},
+		{"dns", &FetchError{Kind: KindDNS}, false},
+		{"503", &FetchError{Kind: KindServer, StatusCode: http.StatusServiceUnavailable}, true},
+		{"429", &FetchError{Kind: KindClient, StatusCode: http.StatusTooManyRequests}, true},
+		{"404", &FetchError{Kind: KindClient, StatusCode: http.StatusNotFound}, false},
+		{"disallowed", &FetchError{Kind: KindDisallowed}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRule is a single Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	pattern string
+	re      *regexp.Regexp
+	allow   bool
+}
+
+// robotsRules are the rules of one host that apply to our user agent.
+type robotsRules struct {
+	rules       []robotsRule
+	crawlDelay  time.Duration
+	disallowAll bool
+}
+
+// allowed reports whether path may be fetched. The longest matching rule wins
+// and Allow wins over Disallow on a tie.
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > best || (len(rule.pattern) == best && rule.allow) {
+			best, allow = len(rule.pattern), rule.allow
+		}
+	}
+	return allow
+}
+
+// compileRobotsPattern turns a robots.txt path pattern with * and $ into a regexp.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// parseRobots extracts the group of a robots.txt that applies to the product
+// token userAgent, falling back to the "*" group when no group names it.
+func parseRobots(body []byte, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// an empty Disallow allows everything and carries no rule
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, robotsRule{
+				pattern: value,
+				re:      compileRobotsPattern(value),
+				allow:   field == "allow",
+			})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	// merge every group naming our agent, or the wildcard groups when none does
+	specific, wildcard := &robotsRules{}, &robotsRules{}
+	matched := false
+	for _, g := range groups {
+		for _, a := range g.agents {
+			target := wildcard
+			if a != "*" {
+				if a != agent {
+					continue
+				}
+				target, matched = specific, true
+			}
+			target.rules = append(target.rules, g.rules.rules...)
+			target.crawlDelay = max(target.crawlDelay, g.rules.crawlDelay)
+			break
+		}
+	}
+
+	if matched {
+		return specific
+	}
+	return wildcard
+}
+
+// RobotsChecker fetches and caches robots.txt per host and decides whether a URL may be crawled.
+type RobotsChecker struct {
+	UserAgent  string        // agent name matched against User-agent lines
+	Ignore     bool          // skip all checks, for explicitly authorised crawls
+	RetryAfter time.Duration // how long an unreachable robots.txt keeps its host blocked before it is fetched again
+
+	client  *Client
+	mu      sync.Mutex
+	hosts   map[string]*robotsEntry
+	skipped []string
+}
+
+// robotsEntry is the robots.txt of one host, being fetched until ready is closed.
+type robotsEntry struct {
+	ready chan struct{}
+	rules *robotsRules
+	err   error
+	until time.Time // when the entry has to be fetched again, zero keeps it for the whole run
+}
+
+func (e *robotsEntry) expired() bool {
+	return !e.until.IsZero() && !time.Now().Before(e.until)
+}
+
+// newRobotsChecker returns a checker matching groups against userAgent
+// that downloads robots.txt files through client.
+func newRobotsChecker(userAgent string, client *Client) *RobotsChecker {
+	return &RobotsChecker{
+		UserAgent:  userAgent,
+		RetryAfter: time.Minute,
+		client:     client,
+		hosts:      map[string]*robotsEntry{},
+	}
+}
+
+// Check returns a FetchError of kind KindDisallowed when robots.txt forbids rawURL.
+// Disallowed URLs are remembered and reported by Skipped.
+func (c *RobotsChecker) Check(ctx context.Context, rawURL string) error {
+	if c.Ignore {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return classifyError(rawURL, err)
+	}
+
+	rules, err := c.rulesFor(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	if rules.allowed(path) {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.skipped = append(c.skipped, rawURL)
+	c.mu.Unlock()
+
+	return &FetchError{Kind: KindDisallowed, URL: rawURL, Err: errors.New("disallowed by robots.txt")}
+}
+
+// Skipped returns the URLs refused so far because of robots.txt.
+func (c *RobotsChecker) Skipped() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.skipped...)
+}
+
+// rulesFor returns the cached rules of u's host, fetching robots.txt on first use.
+// Concurrent callers share a single fetch. A robots.txt that could not be fetched
+// is tried again once RetryAfter has passed.
+func (c *RobotsChecker) rulesFor(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	for {
+		c.mu.Lock()
+		e, ok := c.hosts[u.Host]
+		if !ok {
+			e = &robotsEntry{ready: make(chan struct{})}
+			c.hosts[u.Host] = e
+			c.mu.Unlock()
+
+			e.rules, e.until, e.err = c.fetch(ctx, u)
+			close(e.ready)
+			return e.rules, e.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-e.ready:
+		}
+		if !e.expired() {
+			return e.rules, e.err
+		}
+
+		// drop the stale entry unless another caller already replaced it
+		c.mu.Lock()
+		if c.hosts[u.Host] == e {
+			delete(c.hosts, u.Host)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// fetch downloads and parses the robots.txt of u's host and returns until when the
+// result holds. The Crawl-delay of a host is applied to the client's limiter as its
+// minimum delay.
+func (c *RobotsChecker) fetch(ctx context.Context, u *url.URL) (*robotsRules, time.Time, error) {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", u.Scheme, u.Host)
+	body, status, err := c.client.get(ctx, robotsURL)
+	retry := time.Now().Add(c.RetryAfter)
+
+	var rules *robotsRules
+	var fetchErr *FetchError
+	switch {
+	case ctx.Err() != nil:
+		// the caller gave up, the next one fetches again
+		return nil, time.Now(), ctx.Err()
+	case err == nil:
+		rules = parseRobots(body, c.UserAgent)
+	case errors.As(err, &fetchErr) && fetchErr.Kind == KindClient:
+		// a missing or forbidden robots.txt places no restrictions
+		rules = &robotsRules{}
+	case errors.As(err, &fetchErr) && fetchErr.Kind == KindServer:
+		// an unreachable robots.txt means the whole host is off limits for a while
+		fmt.Fprintf(os.Stderr, "Loaded %s status %d; retrying in %s\n", robotsURL, status, c.RetryAfter)
+		return &robotsRules{disallowAll: true}, retry, nil
+	default:
+		fmt.Fprintln(os.Stderr, "Could not load", robotsURL, ":", err)
+		return nil, retry, err
+	}
+	fmt.Fprintln(os.Stderr, "Loaded", robotsURL, "status", status)
+
+	if rules.crawlDelay > 0 {
+		limit := c.client.Limiter.Limit(u.Hostname())
+		if rules.crawlDelay > limit.MinDelay {
+			limit.MinDelay = rules.crawlDelay
+			c.client.Limiter.SetLimit(u.Hostname(), limit)
+		}
+	}
+
+	return rules, time.Time{}, nil
+}
@@ -0,0 +1,203 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsGroupSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		agent      string
+		path       string
+		allowed    bool
+		crawlDelay time.Duration
+	}{
+		{
+			name:    "wildcard group applies when no group names the agent",
+			body:    "User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /private/\n",
+			agent:   "crawling",
+			path:    "/private/page",
+			allowed: false,
+		},
+		{
+			name:    "named group replaces the wildcard group",
+			body:    "User-agent: *\nDisallow: /\n\nUser-agent: crawling\nDisallow: /bv/\n",
+			agent:   "crawling",
+			path:    "/products/JQ4774/",
+			allowed: true,
+		},
+		{
+			name:    "agent names are matched case-insensitively",
+			body:    "User-agent: Crawling\nDisallow: /bv/\n",
+			agent:   "crawling",
+			path:    "/bv/reviews.djs",
+			allowed: false,
+		},
+		{
+			name:    "consecutive user-agent lines share one group",
+			body:    "User-agent: other\nUser-agent: crawling\nDisallow: /search\n",
+			agent:   "crawling",
+			path:    "/search?q=samba",
+			allowed: false,
+		},
+		{
+			name:    "groups naming the agent are merged",
+			body:    "User-agent: crawling\nDisallow: /a/\n\nUser-agent: crawling\nDisallow: /b/\n",
+			agent:   "crawling",
+			path:    "/b/page",
+			allowed: false,
+		},
+		{
+			name:    "empty disallow allows everything",
+			body:    "User-agent: *\nDisallow:\n",
+			agent:   "crawling",
+			path:    "/anything",
+			allowed: true,
+		},
+		{
+			name:    "comments are ignored",
+			body:    "User-agent: * # everyone\nDisallow: /tmp/ # scratch\n",
+			agent:   "crawling",
+			path:    "/tmp/file",
+			allowed: false,
+		},
+		{
+			name:       "crawl-delay of the selected group",
+			body:       "User-agent: *\nCrawl-delay: 5\n\nUser-agent: crawling\nCrawl-delay: 1.5\n",
+			agent:      "crawling",
+			path:       "/",
+			allowed:    true,
+			crawlDelay: 1500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots([]byte(tt.body), tt.agent)
+			if got := rules.allowed(tt.path); got != tt.allowed {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+			}
+			if rules.crawlDelay != tt.crawlDelay {
+				t.Errorf("crawlDelay = %v, want %v", rules.crawlDelay, tt.crawlDelay)
+			}
+		})
+	}
+}
+
+func TestRobotsRulesAllowed(t *testing.T) {
+	body := `User-agent: *
+Disallow: /bv/
+Allow: /bv/public/
+Disallow: /page
+Allow: /page
+Disallow: /*.json$
+Disallow: /cart*checkout
+`
+	rules := parseRobots([]byte(body), "crawling")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/bv/reviews.djs", false},
+		{"/bv/public/reviews.djs", true}, // the longer Allow wins
+		{"/page", true},                  // Allow wins a tie
+		{"/data.json", false},
+		{"/data.json?v=1", true}, // $ anchors the end of the path
+		{"/cart/step/checkout", false},
+		{"/cart", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rules.allowed(tt.path); got != tt.allowed {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+			}
+		})
+	}
+
+	if (&robotsRules{disallowAll: true}).allowed("/") {
+		t.Error("disallowAll allowed /")
+	}
+}
+
+// newTestClient returns a client without throttling and a single attempt per request.
+func newTestClient() *Client {
+	options := DefaultClientOptions()
+	options.Retry.MaxAttempts = 1
+	options.Limiter = NewRateLimiter(HostLimit{}, nil)
+	return NewClient(options)
+}
+
+func TestRobotsCheckerFetchesOncePerHost(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fetches.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte("User-agent: *\nDisallow: /bv/\n"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Robots.Check(context.Background(), server.URL+"/products/A/"); err != nil {
+				t.Errorf("Check: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", n)
+	}
+	if err := client.Robots.Check(context.Background(), server.URL+"/bv/reviews.djs"); !IsDisallowed(err) {
+		t.Errorf("Check of a disallowed URL = %v, want a disallowed error", err)
+	}
+}
+
+func TestRobotsCheckerRetriesUnreachable(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" && fetches.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient()
+	client.Robots.RetryAfter = 50 * time.Millisecond
+	target := server.URL + "/products/A/"
+
+	if err := client.Robots.Check(context.Background(), target); !IsDisallowed(err) {
+		t.Fatalf("Check after a 503 = %v, want a disallowed error", err)
+	}
+	if err := client.Robots.Check(context.Background(), target); !IsDisallowed(err) {
+		t.Fatalf("Check within RetryAfter = %v, want a disallowed error", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("robots.txt fetched %d times within RetryAfter, want 1", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := client.Robots.Check(context.Background(), target); err != nil {
+		t.Fatalf("Check after RetryAfter = %v, want nil", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("robots.txt fetched %d times, want 2", n)
+	}
+}
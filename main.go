@@ -1,35 +1,62 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-
-	"github.com/nahidhasan98/crawling/export"
-	"github.com/nahidhasan98/crawling/model"
-	"github.com/nahidhasan98/crawling/product"
+	"os"
+	"strings"
 )
 
-func main() {
-	fmt.Println("Programming is running...")
+const usage = `Usage: crawling <command> [flags]
+
+Commands:
+  discover  gather product IDs from the list API, sitemap or keyword search and print them
+  fetch     fetch product details for the given IDs or an ID file
+  export    convert a saved JSON dump to xlsx, csv or json
+  crawl     discover, fetch and export in one run (the default)
+  config    "config print" shows the effective configuration
+  rules     "rules print" shows the extraction rules, "rules check" tries them on saved pages
+
+Run "crawling <command> -h" for the flags of a command.
+`
+
+// commands maps every subcommand to the function running it with its arguments.
+var commands = map[string]func(args []string) error{
+	"discover": runDiscover,
+	"fetch":    runFetch,
+	"export":   runExport,
+	"crawl":    runCrawl,
+	"config":   runConfig,
+	"rules":    runRules,
+}
 
-	productIDs := product.GatherIDs(300)
+func main() {
+	args := os.Args[1:]
 
-	var products []model.Product
-	for i := 0; i < len(productIDs); i++ {
-		fmt.Println("Getting product", i+1, ":")
+	// without a subcommand behave like the original program and crawl
+	name := "crawl"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-		tempProduct := product.GetDetails(productIDs[i])
-		products = append(products, *tempProduct)
+	if name == "help" {
+		fmt.Print(usage)
+		return
 	}
 
-	fmt.Println("Writting data to file...")
-	err1 := export.WriteToFile(products)
-	if err1 != nil {
-		fmt.Println("Error exporting to file:", err1)
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
 	}
 
-	fmt.Println("Exporting data to Spreadsheet...")
-	err := export.Spreadsheet(products)
+	err := run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		fmt.Println("Error exporting to Spreadsheet:", err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 }
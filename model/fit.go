@@ -0,0 +1,52 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Labels of SizeFit, from the left end of the fit bar to the right.
+const (
+	FitRunsSmall  = "runs small"
+	FitTrueToSize = "true to size"
+	FitRunsLarge  = "runs large"
+)
+
+// FitLabels lists every SizeFit label.
+var FitLabels = []string{FitRunsSmall, FitTrueToSize, FitRunsLarge}
+
+// SizeFit is where the marker sits on the fit bar of the product page.
+type SizeFit struct {
+	Score float64 // 0 runs small to 100 runs large, 50 is true to size
+	Label string  // one of FitLabels
+}
+
+// NewSizeFit returns the fit of a fit bar position, labelled true to size
+// within 10 points of the middle.
+func NewSizeFit(score float64) *SizeFit {
+	label := FitTrueToSize
+	switch {
+	case score < 40:
+		label = FitRunsSmall
+	case score > 60:
+		label = FitRunsLarge
+	}
+	return &SizeFit{Score: score, Label: label}
+}
+
+// senseOfSizeRegex finds the marker position in a SenseOfSize such as "Appropriate: 37.5%".
+var senseOfSizeRegex = regexp.MustCompile(`(\d+(\.\d+)?)%`)
+
+// ParseSenseOfSize returns the fit described by a SenseOfSize, nil when it holds no position.
+func ParseSenseOfSize(senseOfSize string) *SizeFit {
+	match := senseOfSizeRegex.FindStringSubmatch(senseOfSize)
+	if match == nil {
+		return nil
+	}
+
+	score, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil
+	}
+	return NewSizeFit(score)
+}
@@ -0,0 +1,59 @@
+package model
+
+import "encoding/json"
+
+// NextData is the part of a product page's __NEXT_DATA__ payload the crawler reads.
+// Nested objects are pointers so that missing parts of the payload can be told apart
+// from empty ones.
+type NextData struct {
+	Props struct {
+		PageProps struct {
+			Apis struct {
+				PdpInitialProps *PdpInitialProps `json:"pdpInitialProps"`
+			} `json:"apis"`
+		} `json:"pageProps"`
+	} `json:"props"`
+}
+
+// PdpInitialProps keeps the detail API payload undecoded, so it can be decoded both
+// into DetailApi and generically for the extraction rules without parsing the page twice.
+type PdpInitialProps struct {
+	DetailApi json.RawMessage `json:"detailApi"`
+}
+
+type DetailApi struct {
+	Product *DetailProduct `json:"product"`
+}
+
+type DetailProduct struct {
+	Article *DetailArticle `json:"article"`
+	Model   *DetailModel   `json:"model"`
+}
+
+type DetailArticle struct {
+	ArticleCode string        `json:"articleCode"`
+	Image       *ArticleImage `json:"image"`
+	Skus        []ArticleSku  `json:"skus"`
+}
+
+type ArticleSku struct {
+	SizeName      string `json:"sizeName"`
+	SkuCode       string `json:"skuCode"`
+	StockStatus   string `json:"stockStatus"`   // e.g. IN_STOCK, LOW_STOCK, OUT_OF_STOCK
+	StockQuantity *int   `json:"stockQuantity"` // absent unless the shop exposes it
+}
+
+type ArticleImage struct {
+	Details []ImageDetail `json:"details"`
+}
+
+type ImageDetail struct {
+	ImageURL struct {
+		Large string `json:"large"`
+	} `json:"imageUrl"`
+}
+
+type DetailModel struct {
+	ModelCode string          `json:"modelCode"`
+	Articles  []DetailArticle `json:"articles"`
+}
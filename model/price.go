@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Price holds amounts in integer minor units of Currency. The yen has no minor
+// unit, so a Current of 16500 with Currency "JPY" is ¥16,500.
+type Price struct {
+	Current         int64  // what the product sells for now
+	Original        int64  // list price before any sale, equal to Current when not on sale
+	DiscountPercent int    // reduction of Current against Original, rounded
+	TaxIncluded     bool   // whether the amounts include consumption tax
+	Currency        string // ISO 4217 code
+}
+
+// minorUnitDigits lists the currencies whose minor unit is not a hundredth.
+var minorUnitDigits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// MinorUnitDigits returns the number of decimal digits of the minor unit of currency.
+func MinorUnitDigits(currency string) int {
+	if digits, ok := minorUnitDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Major returns amount, given in minor units of the price's currency, in major units.
+func (p Price) Major(amount int64) float64 {
+	value := float64(amount)
+	for i := 0; i < MinorUnitDigits(p.Currency); i++ {
+		value /= 10
+	}
+	return value
+}
+
+// UnmarshalJSON also reads the yen amount string earlier versions stored as the price.
+func (p *Price) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if json.Unmarshal(data, &legacy) == nil {
+		*p = Price{}
+		legacy = strings.TrimSpace(legacy)
+		if legacy == "" {
+			return nil
+		}
+
+		amount, err := strconv.ParseInt(legacy, 10, 64)
+		if err != nil {
+			return err
+		}
+		*p = Price{
+			Current:     amount,
+			Original:    amount,
+			TaxIncluded: true,
+			Currency:    "JPY",
+		}
+		return nil
+	}
+
+	type plain Price
+	return json.Unmarshal(data, (*plain)(p))
+}
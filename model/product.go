@@ -1,28 +1,36 @@
 package model
 
-type ProductIDs struct {
-	List []string `json:"articles_sort_list"`
-}
-
-type data struct {
-	Value string `json:"value"`
-}
+import (
+	"encoding/json"
+	"time"
+)
 
-type sizeData struct {
-	Body   map[string]map[string]data `json:"body"`
-	Header map[string]map[string]data `json:"header"`
-}
-
-type SizeTale struct {
-	SizeChart map[string]sizeData `json:"size_chart"`
+type ProductIDs struct {
+	List       []string `json:"articles_sort_list"`
+	TotalCount int      `json:"total_count"` // products matching the query, zero when not reported
+	LastPage   int      `json:"last_page"`   // number of the last page, zero when not reported
 }
 
 type ReviewDetails struct {
-	Date        string
-	Rating      string
-	Title       string
-	Description string
-	ReviewerID  string
+	ID             string `json:",omitempty"` // Bazaarvoice review ID, used to drop duplicates across pages
+	Date           string
+	Submitted      *time.Time `json:",omitempty"` // Date parsed, nil when it could not be
+	Rating         string
+	Score          float64 `json:",omitempty"` // Rating as a number, e.g. 4 for "4 / 5"
+	Title          string
+	Description    string
+	ReviewerID     string
+	HelpfulVotes   int
+	UnhelpfulVotes int
+	Fit            int      `json:",omitempty"` // secondary ratings on the Bazaarvoice slider, 0 when not given
+	Length         int      `json:",omitempty"`
+	Quality        int      `json:",omitempty"`
+	Comfort        int      `json:",omitempty"`
+	SizePurchased  string   `json:",omitempty"`
+	Height         string   `json:",omitempty"` // reviewer's height as they entered it
+	UsualSize      string   `json:",omitempty"` // size the reviewer usually wears
+	Photos         []string `json:",omitempty"`
+	BrandResponse  string   `json:",omitempty"` // the shop's reply to the review
 }
 
 type Review struct {
@@ -34,6 +42,24 @@ type Review struct {
 	QualityOfMaterial     string
 	Comfort               string
 	Details               []ReviewDetails
+
+	Average          float64 `json:",omitempty"` // Rating as a number of stars
+	Count            int     `json:",omitempty"` // NumberOfReviews as a number
+	RecommendPercent float64 `json:",omitempty"` // RecommendedRate as a number, e.g. 85 for "85%"
+	Histogram        [5]int  // reviews per star, index 0 holding the one-star reviews; all zero when unknown
+	FitScore         float64 `json:",omitempty"` // secondary rating averages on the 1-5 scale of the reviews
+	LengthScore      float64 `json:",omitempty"`
+	QualityScore     float64 `json:",omitempty"`
+	ComfortScore     float64 `json:",omitempty"`
+}
+
+// SizeStock is the availability of one size of a product.
+type SizeStock struct {
+	Label    string
+	SKU      string `json:",omitempty"`
+	InStock  bool
+	LowStock bool // only a few left
+	Quantity *int `json:",omitempty"` // units left, nil when the shop does not tell
 }
 
 type DescriptionDetails struct {
@@ -50,13 +76,32 @@ type Product struct {
 	ImageURL        []string
 	Category        string
 	Name            string
-	Price           string
-	Currency        string
+	Price           Price
 	AvailableSize   []string
+	Stock           []SizeStock `json:",omitempty"`
 	SenseOfSize     string
+	Fit             *SizeFit `json:",omitempty"` // numeric sense of size, nil when the page has no fit bar
 	Description     DescriptionDetails
-	TaleOfSize      SizeTale
+	TaleOfSize      SizeChart
 	SpecialFunction string
 	Review          Review
 	KWs             []string
+	SearchQueries   []string          `json:",omitempty"` // keywords whose search found the product
+	Variants        []string          `json:",omitempty"` // article IDs of every colorway of the model, this one included
+	MissingData     []string          `json:",omitempty"` // __NEXT_DATA__ paths that were expected but absent
+	FieldSources    map[string]string `json:",omitempty"` // field name to json, html or none, to spot markup drift
+}
+
+// UnmarshalJSON also fills Fit from the SenseOfSize of products saved before Fit existed.
+func (p *Product) UnmarshalJSON(data []byte) error {
+	type plain Product
+	err := json.Unmarshal(data, (*plain)(p))
+	if err != nil {
+		return err
+	}
+
+	if p.Fit == nil {
+		p.Fit = ParseSenseOfSize(p.SenseOfSize)
+	}
+	return nil
 }
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ListQuery selects the products returned by the product list API.
+// Zero fields are left out of the request.
+type ListQuery struct {
+	Keyword   string            `json:"keyword,omitempty"`    // free text search term
+	Gender    string            `json:"gender,omitempty"`     // mens, womens, kids, ...
+	Category  string            `json:"category,omitempty"`   // e.g. footwear, wear, accessories
+	BrandLine string            `json:"brand_line,omitempty"` // e.g. originals, performance
+	MinPrice  int               `json:"min_price,omitempty"`  // yen, inclusive
+	MaxPrice  int               `json:"max_price,omitempty"`  // yen, inclusive
+	Sort      string            `json:"sort,omitempty"`       // sort order understood by the API
+	PageSize  int               `json:"page_size,omitempty"`  // IDs per page, zero uses the site default
+	Extra     map[string]string `json:"extra,omitempty"`      // any other parameter, e.g. sale items; wins over the fields above
+}
+
+// Values encodes the query as list API parameters for the given page.
+func (q ListQuery) Values(page, defaultPageSize int) url.Values {
+	values := url.Values{}
+
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("q", q.Keyword)
+	set("gender", q.Gender)
+	set("category", q.Category)
+	set("group", q.BrandLine)
+	if q.MinPrice > 0 || q.MaxPrice > 0 {
+		set("price", fmt.Sprintf("%s-%s", priceBound(q.MinPrice), priceBound(q.MaxPrice)))
+	}
+	set("order", q.Sort)
+
+	pageSize := q.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	values.Set("limit", strconv.Itoa(pageSize))
+	values.Set("page", strconv.Itoa(page))
+
+	for key, value := range q.Extra {
+		values.Set(key, value)
+	}
+
+	return values
+}
+
+// priceBound formats one end of a price range, leaving an open end empty.
+func priceBound(price int) string {
+	if price <= 0 {
+		return ""
+	}
+	return strconv.Itoa(price)
+}
+
+// Discovery records how the crawled product IDs were found, so an output can be
+// traced back to the filters that produced it.
+type Discovery struct {
+	Source    string              `json:"source"` // list, sitemap or search
+	Query     ListQuery           `json:"query"`
+	Keywords  []string            `json:"keywords,omitempty"`  // search terms merged into the IDs
+	Matches   map[string][]string `json:"matches,omitempty"`   // product ID to the keywords that found it
+	Requested int                 `json:"requested"`           // the ID limit asked for
+	Found     int                 `json:"found"`               // IDs actually gathered
+	Available int                 `json:"available,omitempty"` // products matching the query, when the API reports it
+}
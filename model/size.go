@@ -0,0 +1,128 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type data struct {
+	Value string `json:"value"`
+}
+
+type sizeData struct {
+	Name   string                     `json:"name"` // not sent for every chart
+	Body   map[string]map[string]data `json:"body"`
+	Header map[string]map[string]data `json:"header"`
+}
+
+// SizeTale is the response of the size chart API. Charts, rows and cells are
+// objects keyed by their position; Normalize turns them into a SizeChart.
+type SizeTale struct {
+	SizeChart map[string]sizeData `json:"size_chart"`
+}
+
+// SizeChart holds every size table of a product, e.g. the JP/US/UK/EU conversion
+// of shoes next to the body measurements of garments.
+type SizeChart struct {
+	Charts []SizeTable
+}
+
+// SizeTable is one named size table with its header and body rows in order.
+type SizeTable struct {
+	Name   string
+	Header [][]string
+	Rows   [][]SizeCell
+}
+
+// SizeCell is one cell of a size table. Measurements such as "90cm" are also
+// parsed into their number and unit.
+type SizeCell struct {
+	Text  string
+	Value float64 `json:",omitempty"`
+	Unit  string  `json:",omitempty"`
+}
+
+// measurementRegex matches a number optionally followed by a unit, e.g. "90cm", "26.5" or "7 inch".
+// Sizes such as "2XL" are not measurements.
+var measurementRegex = regexp.MustCompile(`(?i)^(-?\d+(?:\.\d+)?)\s*(cm|mm|m|kg|g|inch|in|インチ|")?$`)
+
+// parseSizeCell returns the cell of text with its measurement parsed when it holds one.
+func parseSizeCell(text string) SizeCell {
+	cell := SizeCell{Text: strings.TrimSpace(text)}
+
+	match := measurementRegex.FindStringSubmatch(cell.Text)
+	if match != nil {
+		cell.Value, _ = strconv.ParseFloat(match[1], 64)
+		cell.Unit = strings.TrimSpace(match[2])
+	}
+
+	return cell
+}
+
+// positions returns the keys of a positional object in numeric order.
+func positions[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA != nil || errB != nil {
+			return keys[i] < keys[j]
+		}
+		return a < b
+	})
+	return keys
+}
+
+// Normalize returns every chart of the response in order. Charts without a name
+// are called "Chart 1", "Chart 2" and so on.
+func (t SizeTale) Normalize() SizeChart {
+	var chart SizeChart
+
+	for i, key := range positions(t.SizeChart) {
+		raw := t.SizeChart[key]
+
+		table := SizeTable{Name: strings.TrimSpace(raw.Name)}
+		if table.Name == "" {
+			table.Name = fmt.Sprintf("Chart %d", i+1)
+		}
+
+		for _, rowKey := range positions(raw.Header) {
+			var row []string
+			for _, cellKey := range positions(raw.Header[rowKey]) {
+				row = append(row, strings.TrimSpace(raw.Header[rowKey][cellKey].Value))
+			}
+			table.Header = append(table.Header, row)
+		}
+
+		for _, rowKey := range positions(raw.Body) {
+			var row []SizeCell
+			for _, cellKey := range positions(raw.Body[rowKey]) {
+				row = append(row, parseSizeCell(raw.Body[rowKey][cellKey].Value))
+			}
+			table.Rows = append(table.Rows, row)
+		}
+
+		chart.Charts = append(chart.Charts, table)
+	}
+
+	return chart
+}
+
+// UnmarshalJSON also reads the raw size chart API responses earlier versions stored.
+func (c *SizeChart) UnmarshalJSON(data []byte) error {
+	var legacy SizeTale
+	if json.Unmarshal(data, &legacy) == nil && legacy.SizeChart != nil {
+		*c = legacy.Normalize()
+		return nil
+	}
+
+	type plain SizeChart
+	return json.Unmarshal(data, (*plain)(c))
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSizeTaleNormalize(t *testing.T) {
+	raw := `{"size_chart": {
+		"10": {"name": "Body", "header": {"0": {"0": {"value": "Size"}}}, "body": {"0": {"0": {"value": "XL"}}}},
+		"2": {"header": {"0": {"0": {"value": "JP"}, "1": {"value": "US"}}},
+			"body": {
+				"10": {"0": {"value": "28.0"}, "1": {"value": "10"}},
+				"2": {"0": {"value": "23.0"}, "1": {"value": "5"}},
+				"1": {"0": {"value": "22.5"}, "10": {"value": "4.5"}, "2": {"value": "EU 36"}}
+			}},
+		"1": {"name": " Shoes ", "header": {}, "body": {}}
+	}}`
+
+	var tale SizeTale
+	if err := json.Unmarshal([]byte(raw), &tale); err != nil {
+		t.Fatal(err)
+	}
+	chart := tale.Normalize()
+
+	var names []string
+	for _, table := range chart.Charts {
+		names = append(names, table.Name)
+	}
+	if want := []string{"Shoes", "Chart 2", "Body"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("chart names = %q, want %q", names, want)
+	}
+
+	table := chart.Charts[1]
+	if want := [][]string{{"JP", "US"}}; !reflect.DeepEqual(table.Header, want) {
+		t.Errorf("header = %q, want %q", table.Header, want)
+	}
+
+	var rows [][]string
+	for _, row := range table.Rows {
+		var texts []string
+		for _, cell := range row {
+			texts = append(texts, cell.Text)
+		}
+		rows = append(rows, texts)
+	}
+	want := [][]string{{"22.5", "EU 36", "4.5"}, {"23.0", "5"}, {"28.0", "10"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestParseSizeCell(t *testing.T) {
+	tests := []struct {
+		text string
+		want SizeCell
+	}{
+		{"90cm", SizeCell{Text: "90cm", Value: 90, Unit: "cm"}},
+		{" 26.5 ", SizeCell{Text: "26.5", Value: 26.5}},
+		{"7 inch", SizeCell{Text: "7 inch", Value: 7, Unit: "inch"}},
+		{"2XL", SizeCell{Text: "2XL"}},
+		{"J/S", SizeCell{Text: "J/S"}},
+		{"", SizeCell{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := parseSizeCell(tt.text); got != tt.want {
+				t.Errorf("parseSizeCell(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	m := map[string]int{"10": 0, "9": 0, "1": 0, "0": 0, "100": 0}
+	want := []string{"0", "1", "9", "10", "100"}
+	if got := positions(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("positions = %q, want %q", got, want)
+	}
+}
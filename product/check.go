@@ -0,0 +1,75 @@
+package product
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// listFields are the fields whose extractors read every match instead of the first.
+var listFields = []string{"Breadcrumb", "Model", "AvailableSize", "Description.Itemization", "KWs", "Review.Details.Photos"}
+
+// elementFields match repeated elements; the fields named below them are read
+// relative to those elements.
+var elementFields = []string{"Stock", "Review.Histogram", "Review.Details"}
+
+// RuleResult is what the rule of one field extracted from a saved page.
+type RuleResult struct {
+	Field  string
+	Source string // json, html or none
+	Values []string
+}
+
+// CheckRules applies r to a saved product page or Bazaarvoice reviews.djs response
+// and reports what every field of that kind of document extracted. The fields of
+// single sizes and reviews are checked against the first one.
+func CheckRules(body []byte, r *rules.Rules) ([]RuleResult, error) {
+	reviewDoc, err := reviewDocument(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var ex *extractor
+	if reviewDoc != nil {
+		ex = newExtractor(r, reviewDoc.Selection, nil)
+	} else {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		ex = newExtractor(r, doc.Selection, decodePageData(doc).detail)
+	}
+
+	var results []RuleResult
+	for _, field := range rules.Names {
+		if strings.HasPrefix(field, "Review.") != (reviewDoc != nil) {
+			continue
+		}
+
+		parent := field[:max(strings.LastIndex(field, "."), 0)]
+
+		var values []string
+		switch {
+		case slices.Contains(elementFields, field):
+			if n := ex.elements(field).Length(); n > 0 {
+				values = []string{fmt.Sprintf("%d elements", n)}
+			}
+		case slices.Contains(elementFields, parent):
+			values = ex.find(ex.elements(parent).First(), field, slices.Contains(listFields, field))
+		default:
+			values = ex.find(ex.doc, field, slices.Contains(listFields, field))
+		}
+
+		results = append(results, RuleResult{
+			Field:  field,
+			Source: ex.sources[field],
+			Values: values,
+		})
+	}
+
+	return results, nil
+}
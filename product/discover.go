@@ -0,0 +1,179 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// GatherIDs pages through the list API of site filtered by query until limit IDs are collected
+// or the API runs out of products. IDs repeated across pages are kept once. The returned
+// Discovery records the query and how many IDs were requested, found and, when the API
+// reports it, available in total.
+// IDs collected before a failure or cancellation of ctx are returned alongside the error.
+func GatherIDs(ctx context.Context, client *helper.Client, site config.Site, query model.ListQuery, limit int) ([]string, model.Discovery, error) {
+	productIDs, available, err := pageIDs(ctx, client, site.ListAPI, query, site.PageSize, limit)
+
+	discovery := model.Discovery{
+		Source:    "list",
+		Query:     query,
+		Requested: limit,
+		Found:     len(productIDs),
+		Available: available,
+	}
+	printDiscovery(discovery)
+
+	return productIDs, discovery, err
+}
+
+// SearchIDs searches site for every keyword, paging through all results up to limit IDs in
+// total. Each keyword is combined with the filters of query. The IDs are merged in keyword
+// order without duplicates and Discovery.Matches lists the keywords that found each ID.
+// IDs collected before a failure or cancellation of ctx are returned alongside the error.
+func SearchIDs(ctx context.Context, client *helper.Client, site config.Site, query model.ListQuery, keywords []string, limit int) ([]string, model.Discovery, error) {
+	discovery := model.Discovery{
+		Source:    "search",
+		Query:     query,
+		Keywords:  keywords,
+		Requested: limit,
+		Matches:   map[string][]string{},
+	}
+
+	var productIDs []string
+	var err error
+	for _, keyword := range keywords {
+		q := query
+		q.Keyword = keyword
+
+		var found []string
+		var available int
+		found, available, err = pageIDs(ctx, client, site.SearchAPI, q, site.PageSize, limit)
+		fmt.Fprintf(os.Stderr, "Search %q found %d product IDs", keyword, len(found))
+		if available > 0 {
+			fmt.Fprintf(os.Stderr, " (%d available)", available)
+		}
+		fmt.Fprintln(os.Stderr)
+
+		for _, id := range found {
+			if _, ok := discovery.Matches[id]; !ok {
+				productIDs = append(productIDs, id)
+			}
+			discovery.Matches[id] = append(discovery.Matches[id], keyword)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	discovery.Available = len(productIDs)
+	if len(productIDs) > limit {
+		for _, id := range productIDs[limit:] {
+			delete(discovery.Matches, id)
+		}
+		productIDs = productIDs[:limit]
+	}
+	discovery.Found = len(productIDs)
+	printDiscovery(discovery)
+
+	return productIDs, discovery, err
+}
+
+// MergeDiscovery puts the IDs of a search in front of those of another source, keeping each
+// ID once and at most limit IDs overall. The merged Discovery keeps the source of the other
+// one and the keywords and matches of the search.
+func MergeDiscovery(productIDs []string, discovery model.Discovery, searchIDs []string, search model.Discovery, limit int) ([]string, model.Discovery) {
+	seen := map[string]bool{}
+	var merged []string
+	for _, id := range append(append([]string{}, searchIDs...), productIDs...) {
+		if !seen[id] && len(merged) < limit {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+
+	discovery.Keywords = search.Keywords
+	discovery.Matches = map[string][]string{}
+	for id, keywords := range search.Matches {
+		if seen[id] {
+			discovery.Matches[id] = keywords
+		}
+	}
+	discovery.Requested = limit
+	discovery.Found = len(merged)
+
+	return merged, discovery
+}
+
+// pageIDs pages through a list style API at apiURL filtered by query until limit IDs are
+// collected or the API runs out of products, keeping repeated IDs once. It also returns the
+// total number of matching products when the API reports it.
+func pageIDs(ctx context.Context, client *helper.Client, apiURL string, query model.ListQuery, defaultPageSize, limit int) ([]string, int, error) {
+	var productIDs []string
+	seen := map[string]bool{}
+	available := 0
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	for page := 1; len(productIDs) < limit; page++ {
+		URL := fmt.Sprintf("%s?%s", apiURL, query.Values(page, defaultPageSize).Encode())
+
+		responseBody, _, err := client.GETRequest(ctx, URL)
+		if err != nil {
+			return productIDs, available, err
+		}
+
+		var tempList model.ProductIDs
+		err = json.Unmarshal(responseBody, &tempList)
+		if err != nil {
+			return productIDs, available, helper.DecodeError(URL, err)
+		}
+
+		if tempList.TotalCount > 0 {
+			available = tempList.TotalCount
+		}
+
+		added := 0
+		for _, id := range tempList.List {
+			if !seen[id] {
+				seen[id] = true
+				productIDs = append(productIDs, id)
+				added++
+			}
+		}
+
+		// an empty page, a page of repeats or a short page means there is nothing further
+		if added == 0 || len(tempList.List) < pageSize {
+			break
+		}
+		if tempList.LastPage > 0 && page >= tempList.LastPage {
+			break
+		}
+		if tempList.TotalCount > 0 && page*pageSize >= tempList.TotalCount {
+			break
+		}
+	}
+
+	if len(productIDs) > limit {
+		productIDs = productIDs[:limit]
+	}
+
+	return productIDs, available, nil
+}
+
+// printDiscovery reports how many IDs a discovery run found.
+func printDiscovery(discovery model.Discovery) {
+	fmt.Fprintf(os.Stderr, "Discovered %d of %d requested product IDs", discovery.Found, discovery.Requested)
+	if discovery.Available > 0 {
+		fmt.Fprintf(os.Stderr, " (%d available)", discovery.Available)
+	}
+	fmt.Fprintln(os.Stderr)
+}
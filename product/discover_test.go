@@ -0,0 +1,163 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// newTestClient returns a client without throttling, robots.txt checks or retries.
+func newTestClient() *helper.Client {
+	options := helper.DefaultClientOptions()
+	options.IgnoreRobots = true
+	options.Retry.MaxAttempts = 1
+	options.Limiter = helper.NewRateLimiter(helper.HostLimit{}, nil)
+	return helper.NewClient(options)
+}
+
+// fullPages returns pages of size IDs each, numbered across pages, that never run out.
+func fullPages(size int) func(page int) model.ProductIDs {
+	return func(page int) model.ProductIDs {
+		var list []string
+		for i := 0; i < size; i++ {
+			list = append(list, fmt.Sprintf("P%d", (page-1)*size+i))
+		}
+		return model.ProductIDs{List: list}
+	}
+}
+
+// fixedPages serves the given pages and empty pages after them.
+func fixedPages(pages ...model.ProductIDs) func(page int) model.ProductIDs {
+	return func(page int) model.ProductIDs {
+		if page > len(pages) {
+			return model.ProductIDs{}
+		}
+		return pages[page-1]
+	}
+}
+
+func TestPageIDs(t *testing.T) {
+	list := func(ids ...string) model.ProductIDs { return model.ProductIDs{List: ids} }
+
+	tests := []struct {
+		name      string
+		pages     func(page int) model.ProductIDs
+		limit     int
+		want      []string
+		requests  int32
+		available int
+	}{
+		{
+			name:     "empty page",
+			pages:    fixedPages(list("a", "b"), list()),
+			limit:    10,
+			want:     []string{"a", "b"},
+			requests: 2,
+		},
+		{
+			name:     "page of repeats",
+			pages:    fixedPages(list("a", "b"), list("a", "b"), list("c", "d")),
+			limit:    10,
+			want:     []string{"a", "b"},
+			requests: 2,
+		},
+		{
+			name:     "short page",
+			pages:    fixedPages(list("a", "b"), list("c"), list("d", "e")),
+			limit:    10,
+			want:     []string{"a", "b", "c"},
+			requests: 2,
+		},
+		{
+			name: "last page",
+			pages: func(page int) model.ProductIDs {
+				ids := fullPages(2)(page)
+				ids.LastPage = 2
+				return ids
+			},
+			limit:    10,
+			want:     []string{"P0", "P1", "P2", "P3"},
+			requests: 2,
+		},
+		{
+			name: "total count",
+			pages: func(page int) model.ProductIDs {
+				ids := fullPages(2)(page)
+				ids.TotalCount = 3
+				return ids
+			},
+			limit:     10,
+			want:      []string{"P0", "P1", "P2", "P3"},
+			requests:  2,
+			available: 3,
+		},
+		{
+			name:     "dedupe across pages",
+			pages:    fixedPages(list("a", "b"), list("b", "c"), list("d", "a")),
+			limit:    10,
+			want:     []string{"a", "b", "c", "d"},
+			requests: 4,
+		},
+		{
+			name:     "limit",
+			pages:    fullPages(2),
+			limit:    3,
+			want:     []string{"P0", "P1", "P2"},
+			requests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				json.NewEncoder(w).Encode(tt.pages(page))
+			}))
+			defer server.Close()
+
+			got, available, err := pageIDs(context.Background(), newTestClient(), server.URL, model.ListQuery{}, 2, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IDs = %q, want %q", got, tt.want)
+			}
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("%d pages requested, want %d", n, tt.requests)
+			}
+			if available != tt.available {
+				t.Errorf("available = %d, want %d", available, tt.available)
+			}
+		})
+	}
+}
+
+func TestPageIDsKeepsIDsOfFailedPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(model.ProductIDs{List: []string{"a", "b"}})
+	}))
+	defer server.Close()
+
+	got, _, err := pageIDs(context.Background(), newTestClient(), server.URL, model.ListQuery{}, 2, 10)
+	if err == nil {
+		t.Error("error = nil, want the failure of page 2")
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package product
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// Values of model.Product.FieldSources.
+const (
+	sourceJSON = "json" // taken from __NEXT_DATA__
+	sourceHTML = "html" // scraped from the markup
+	sourceNone = "none" // found in neither
+)
+
+// extractor reads the fields described by rules from a document and remembers
+// where each value came from.
+type extractor struct {
+	rules   *rules.Rules
+	doc     *goquery.Selection
+	data    any // generic detail API payload, nil when the document has none
+	sources map[string]string
+}
+
+func newExtractor(r *rules.Rules, doc *goquery.Selection, data any) *extractor {
+	return &extractor{
+		rules:   r,
+		doc:     doc,
+		data:    data,
+		sources: map[string]string{},
+	}
+}
+
+// text returns the first value of field.
+func (e *extractor) text(field string) string {
+	return e.textIn(e.doc, field)
+}
+
+// textIn returns the first value of field below sel.
+func (e *extractor) textIn(sel *goquery.Selection, field string) string {
+	values := e.find(sel, field, false)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// list returns every value of field.
+func (e *extractor) list(field string) []string {
+	return e.find(e.doc, field, true)
+}
+
+// listIn returns every value of field below sel.
+func (e *extractor) listIn(sel *goquery.Selection, field string) []string {
+	return e.find(sel, field, true)
+}
+
+// elements returns the matches of the first selector of field matching anything.
+func (e *extractor) elements(field string) *goquery.Selection {
+	for _, selector := range e.rules.Field(field).Selectors {
+		if matches := e.doc.Find(selector); matches.Length() > 0 {
+			e.sources[field] = sourceHTML
+			return matches
+		}
+	}
+
+	e.sources[field] = sourceNone
+	return e.doc.Slice(0, 0)
+}
+
+// matches reports whether a selector of field matches sel itself or an element below it.
+func (e *extractor) matches(sel *goquery.Selection, field string) bool {
+	for _, selector := range e.rules.Field(field).Selectors {
+		if sel.Is(selector) || sel.Find(selector).Length() > 0 {
+			e.sources[field] = sourceHTML
+			return true
+		}
+	}
+
+	e.sources[field] = sourceNone
+	return false
+}
+
+// find applies the rule of field, trying its JSON paths and then its selectors
+// on sel and the elements below it. A selector yields the text of all its matches as one value unless
+// each is set, in which case every match is a value of its own.
+func (e *extractor) find(sel *goquery.Selection, field string, each bool) []string {
+	rule := e.rules.Field(field)
+
+	if e.data != nil {
+		for _, path := range rule.JSON {
+			values := lookup(e.data, path)
+			if len(values) > 0 {
+				e.sources[field] = sourceJSON
+				return values
+			}
+		}
+	}
+
+	for _, selector := range rule.Selectors {
+		matches := sel.Filter(selector).AddSelection(sel.Find(selector))
+
+		var values []string
+		if each {
+			matches.Each(func(i int, s *goquery.Selection) {
+				if value := selectionValue(s, rule.Attr); value != "" {
+					values = append(values, value)
+				}
+			})
+			values = skip(values, rule.Skip)
+		} else if value := selectionValue(skipSelection(matches, rule.Skip), rule.Attr); value != "" {
+			values = append(values, value)
+		}
+
+		if len(values) > 0 {
+			e.sources[field] = sourceHTML
+			return values
+		}
+	}
+
+	e.sources[field] = sourceNone
+	return nil
+}
+
+// selectionValue returns the trimmed attribute attr of the first element of s,
+// or the text of all of s when attr is empty.
+func selectionValue(s *goquery.Selection, attr string) string {
+	if attr == "" {
+		return strings.TrimSpace(s.Text())
+	}
+	value, _ := s.Attr(attr)
+	return strings.TrimSpace(value)
+}
+
+func skip(values []string, n int) []string {
+	if n >= len(values) {
+		return nil
+	}
+	return values[n:]
+}
+
+func skipSelection(s *goquery.Selection, n int) *goquery.Selection {
+	if n == 0 {
+		return s
+	}
+	return s.Slice(min(n, s.Length()), s.Length())
+}
+
+// lookup returns the non-empty string, number and boolean values found at path in a
+// payload decoded with json.Decoder.UseNumber. A "name[]" segment walks every
+// element of the array name.
+func lookup(data any, path string) []string {
+	values := []any{data}
+	for _, key := range strings.Split(path, ".") {
+		key, all := strings.CutSuffix(key, "[]")
+
+		var next []any
+		for _, value := range values {
+			object, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+			child, ok := object[key]
+			if !ok {
+				continue
+			}
+			if !all {
+				next = append(next, child)
+			} else if items, ok := child.([]any); ok {
+				next = append(next, items...)
+			}
+		}
+		values = next
+	}
+
+	var found []string
+	for _, value := range values {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = strings.TrimSpace(v)
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v)
+		}
+		if s != "" {
+			found = append(found, s)
+		}
+	}
+	return found
+}
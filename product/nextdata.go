@@ -0,0 +1,104 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// detailPath is the JSON path of the detail API payload inside __NEXT_DATA__.
+const detailPath = "props.pageProps.apis.pdpInitialProps.detailApi"
+
+// pageData is the decoded __NEXT_DATA__ payload of a product page together with
+// the parts of it that were expected but not found. The detail API payload is kept
+// both typed and generic, the latter for the JSON paths of the extraction rules.
+type pageData struct {
+	product *model.DetailProduct
+	detail  any
+	missing []string
+}
+
+// decodePageData decodes the __NEXT_DATA__ script of doc once for all extractors.
+// Only the detail API payload is decoded a second time, from its raw bytes.
+// An absent or malformed payload is reported as missing rather than failing the page,
+// since most fields can still be scraped from the markup.
+func decodePageData(doc *goquery.Document) *pageData {
+	data := &pageData{}
+
+	body := strings.TrimSpace(doc.Find("script#__NEXT_DATA__").Text())
+	if body == "" {
+		data.missing = append(data.missing, "__NEXT_DATA__")
+		return data
+	}
+
+	var nextData model.NextData
+	err := json.Unmarshal([]byte(body), &nextData)
+	if err != nil {
+		data.missing = append(data.missing, fmt.Sprintf("__NEXT_DATA__ (%v)", err))
+		return data
+	}
+
+	pdp := nextData.Props.PageProps.Apis.PdpInitialProps
+	if pdp == nil {
+		data.missing = append(data.missing, "props.pageProps.apis.pdpInitialProps")
+		return data
+	}
+	if len(pdp.DetailApi) == 0 || string(pdp.DetailApi) == "null" {
+		data.missing = append(data.missing, detailPath)
+		return data
+	}
+
+	var detailApi model.DetailApi
+	err = json.Unmarshal(pdp.DetailApi, &detailApi)
+	if err != nil {
+		data.missing = append(data.missing, fmt.Sprintf("%s (%v)", detailPath, err))
+		return data
+	}
+	if detailApi.Product == nil {
+		data.missing = append(data.missing, detailPath+".product")
+	}
+	data.product = detailApi.Product
+
+	decoder := json.NewDecoder(bytes.NewReader(pdp.DetailApi))
+	decoder.UseNumber()
+	var detail any
+	if decoder.Decode(&detail) == nil {
+		data.detail = detail
+	}
+
+	return data
+}
+
+// article returns the current article, recording it as missing when absent.
+func (d *pageData) article() *model.DetailArticle {
+	if d.product == nil {
+		return nil
+	}
+	if d.product.Article == nil {
+		d.report(".product.article")
+	}
+	return d.product.Article
+}
+
+// productModel returns the model of the product, recording it as missing when absent.
+func (d *pageData) productModel() *model.DetailModel {
+	if d.product == nil {
+		return nil
+	}
+	if d.product.Model == nil {
+		d.report(".product.model")
+	}
+	return d.product.Model
+}
+
+// report records a path below the detail API payload as missing.
+func (d *pageData) report(path string) {
+	if !slices.Contains(d.missing, detailPath+path) {
+		d.missing = append(d.missing, detailPath+path)
+	}
+}
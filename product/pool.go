@@ -0,0 +1,74 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// FetchAll fetches the details of every product ID, extracted as described by r, using up to concurrency parallel workers.
+// Both returned slices follow the order of productIDs: a failed product has a nil entry in
+// products and its error at the same index in errs.
+//
+// Once ctx is cancelled no further products are started; products already being fetched
+// are allowed to finish or time out, and the remaining ones fail with ctx's error.
+//
+// done, when not nil, is called from the worker goroutines as soon as each started
+// product has been fetched, e.g. to checkpoint it.
+func FetchAll(ctx context.Context, client *helper.Client, site config.Site, r *rules.Rules, reviews config.Reviews, productIDs []string, concurrency int, done func(index int, product *model.Product, err error)) ([]*model.Product, []error) {
+	products := make([]*model.Product, len(productIDs))
+	errs := make([]error, len(productIDs))
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// in-flight products must not be aborted by the cancellation itself
+	inFlight := context.WithoutCancel(ctx)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				fmt.Fprintln(os.Stderr, "Getting product", i+1, ":", productIDs[i])
+				products[i], errs[i] = GetDetails(inFlight, client, site, r, reviews, productIDs[i])
+				if done != nil {
+					done(i, products[i], errs[i])
+				}
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(productIDs); next++ {
+		// select picks at random when a worker is idle as well, so check first
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- next:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < len(productIDs); i++ {
+		errs[i] = ctx.Err()
+	}
+
+	return products, errs
+}
@@ -0,0 +1,54 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+func TestFetchAllStopsWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "reviews.djs") {
+			return
+		}
+		w.Write([]byte(`<div class="itemTitle">Samba</div>`))
+	}))
+	defer server.Close()
+
+	client := newTestClient()
+
+	site := config.Default().Site
+	site.Host = server.URL
+	site.SizeChartAPI = server.URL + "/size"
+	site.ReviewAPI = server.URL + "/bv"
+
+	productIDs := make([]string, 50)
+	for i := range productIDs {
+		productIDs[i] = fmt.Sprintf("ID%d", i)
+	}
+
+	// cancel once the first product is done, while its worker is about to ask for more
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var started atomic.Int32
+	_, errs := FetchAll(ctx, client, site, rules.Default(), config.Reviews{}, productIDs, 1, func(int, *model.Product, error) {
+		started.Add(1)
+		cancel()
+	})
+
+	// the dispatcher may already be offering the second product when ctx is cancelled
+	if n := started.Load(); n > 2 {
+		t.Errorf("%d products fetched after cancellation, want at most 2", n)
+	}
+	if errs[len(errs)-1] != context.Canceled {
+		t.Errorf("last product error = %v, want context.Canceled", errs[len(errs)-1])
+	}
+}
@@ -1,173 +1,251 @@
 package product
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/nahidhasan98/crawling/config"
 	"github.com/nahidhasan98/crawling/helper"
 	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/rules"
 )
 
-func GatherIDs(limit int) []string {
-	var productIDs []string
-
-	apiURL := "https://shop.adidas.jp/f/v1/pub/product/list"
-	page := 1
+func getBreadcrumb(ex *extractor) string {
+	return strings.Join(ex.list("Breadcrumb"), " / ")
+}
 
-	for {
-		URL := fmt.Sprintf("%s?gender=mens&limit=120&page=%d", apiURL, page)
+// getModel returns the model code. The breadcrumb links to it as /model/{code}/.
+func getModel(ex *extractor) string {
+	links := ex.list("Model")
+	if len(links) == 0 {
+		return ""
+	}
 
-		response := helper.GETRequest(URL)
-		defer response.Body.Close()
+	productModel := links[len(links)-1]
+	if strings.HasPrefix(productModel, "/model/") {
+		productModel = strings.TrimPrefix(productModel, "/model/")
+		productModel = strings.TrimSuffix(productModel, "/")
+	}
 
-		responseBody, err := io.ReadAll(response.Body)
-		helper.ErrorCheck(err)
+	return productModel
+}
 
-		var tempList model.ProductIDs
-		err = json.Unmarshal(responseBody, &tempList)
-		helper.ErrorCheck(err)
+// getImageURL returns the large image of every detail photo of the article.
+func getImageURL(data *pageData, host string) []string {
+	imageURL := []string{}
 
-		productIDs = append(productIDs, tempList.List...)
+	article := data.article()
+	if article == nil {
+		return imageURL
+	}
+	if article.Image == nil {
+		data.report(".product.article.image")
+		return imageURL
+	}
 
-		if len(productIDs) >= limit {
-			break
+	for _, detail := range article.Image.Details {
+		if detail.ImageURL.Large != "" {
+			imageURL = append(imageURL, fmt.Sprintf("%s%s", host, detail.ImageURL.Large))
 		}
-		page = page + 1
-
-	}
-	if len(productIDs) > limit {
-		productIDs = productIDs[:limit]
 	}
 
-	return productIDs
+	return imageURL
 }
 
-func getBreadcrumb(doc *goquery.Document, product *model.Product) string {
-	productModel := ""
-	breadcrumb := ""
-
-	doc.Find(".breadcrumbListItem a").Each(func(i int, s *goquery.Selection) {
-		if i > 0 {
-			if len(breadcrumb) > 0 {
-				breadcrumb += " / "
-			}
-			breadcrumb += strings.TrimSpace(s.Text())
+// getVariants returns the article IDs of every colorway of the product's model.
+func getVariants(data *pageData) []string {
+	productModel := data.productModel()
+	if productModel == nil {
+		return nil
+	}
 
-			model, exist := s.Attr("href")
-			if exist {
-				if strings.HasPrefix(strings.TrimSpace(model), "/model/") {
-					model = strings.TrimPrefix(model, "/model/")
-					model = strings.TrimSuffix(model, "/")
-				}
-			}
-			productModel = model
+	var variants []string
+	for _, article := range productModel.Articles {
+		if article.ArticleCode != "" {
+			variants = append(variants, article.ArticleCode)
 		}
-	})
-	product.Model = productModel
+	}
 
-	return breadcrumb
+	return variants
 }
 
-func getImageURL(doc *goquery.Document, host string) []string {
-	imageURL := []string{}
-
-	body := doc.Find("script#__NEXT_DATA__").Text()
+func getCategory(ex *extractor) string {
+	return ex.text("Category")
+}
 
-	var bodyInterfacer map[string]interface{}
-	err := json.Unmarshal([]byte(body), &bodyInterfacer)
-	helper.ErrorCheck(err)
+func getName(ex *extractor) string {
+	return ex.text("Name")
+}
 
-	props := bodyInterfacer["props"].(map[string]interface{})
-	pageProps := props["pageProps"].(map[string]interface{})
-	apis := pageProps["apis"].(map[string]interface{})
-	pdpInitialProps := apis["pdpInitialProps"].(map[string]interface{})
-	detailApi := pdpInitialProps["detailApi"].(map[string]interface{})
-	product := detailApi["product"].(map[string]interface{})
-	article := product["article"].(map[string]interface{})
-	image := article["image"].(map[string]interface{})
-	details := image["details"].([]interface{})
+// getPrice returns the current and original price. Prices on the Japanese site
+// include tax unless they are marked 税抜 (before tax).
+func getPrice(ex *extractor) model.Price {
+	price := model.Price{
+		Currency:    currencyCode(ex.text("Price.Currency")),
+		TaxIncluded: !strings.Contains(ex.text("Price.Tax"), "税抜"),
+	}
 
-	for _, v := range details {
-		detail := v.(map[string]interface{})
-		imageUrl := detail["imageUrl"].(map[string]interface{})
-		large := imageUrl["large"].(string)
+	digits := model.MinorUnitDigits(price.Currency)
+	price.Current = parseAmount(ex.text("Price"), digits)
+	price.Original = parseAmount(ex.text("Price.Original"), digits)
 
-		imageURL = append(imageURL, fmt.Sprintf("%s%s", host, large))
+	if price.Original > price.Current {
+		price.DiscountPercent = int(math.Round(float64(price.Original-price.Current) * 100 / float64(price.Original)))
+	} else {
+		price.Original = price.Current
 	}
 
-	return imageURL
+	return price
 }
 
-func getCategory(doc *goquery.Document) string {
-	category := strings.TrimSpace(doc.Find(".groupName").Text())
-	return category
+// currencyCode returns the ISO 4217 code of a currency code or symbol, JPY when unknown.
+func currencyCode(value string) string {
+	switch value = strings.ToUpper(strings.TrimSpace(value)); {
+	case len(value) == 3 && strings.Trim(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "":
+		return value
+	case value == "$":
+		return "USD"
+	case value == "€":
+		return "EUR"
+	default:
+		return "JPY"
+	}
 }
 
-func getName(doc *goquery.Document) string {
-	name := strings.TrimSpace(doc.Find(".itemTitle").Text())
-	return name
-}
+// amountRegex finds the numbers of a displayed price; a trailing % marks a discount rather than an amount.
+var amountRegex = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s*(%?)`)
 
-func getPrice(doc *goquery.Document, product *model.Product) string {
-	price := strings.TrimSpace(doc.Find(".price-value ").Text())
-	price = strings.ReplaceAll(price, ",", "")
-	product.Currency = "¥"
+// parseAmount converts the first amount of a displayed price such as "¥16,500",
+// "16.50" or "30%OFF ¥10,780" to minor units with the given number of digits,
+// returning zero when it holds none.
+func parseAmount(value string, digits int) int64 {
+	for _, match := range amountRegex.FindAllStringSubmatch(value, -1) {
+		if match[2] == "%" {
+			continue
+		}
 
-	return price
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		if err != nil {
+			return 0
+		}
+		return int64(math.Round(amount * math.Pow10(digits)))
+	}
+	return 0
 }
 
-func getAvailableSize(doc *goquery.Document) []string {
+// getAvailableSize returns the sizes in stock, or every size button when the stock is unknown.
+func getAvailableSize(ex *extractor, stock []model.SizeStock) []string {
 	sizes := []string{}
-	doc.Find(".sizeSelectorListItem button").Each(func(i int, s *goquery.Selection) {
-		size := strings.TrimSpace(s.Text())
-		sizes = append(sizes, size)
-	})
+	if len(stock) == 0 {
+		return append(sizes, ex.list("AvailableSize")...)
+	}
+
+	for _, size := range stock {
+		if size.InStock {
+			sizes = append(sizes, size.Label)
+		}
+	}
 	return sizes
 }
 
-func getSenseOfSize(doc *goquery.Document, responseBody []byte) string {
-	percentage := ""
-	classes, exist := doc.Find(".bar .marker").Attr("class")
-	if exist {
-		classList := strings.Split(classes, " ")
+// getStock returns the availability of every size, from the SKUs of the page data
+// or else from the size selector.
+func getStock(data *pageData, ex *extractor) []model.SizeStock {
+	var stock []model.SizeStock
+
+	if article := data.article(); article != nil && len(article.Skus) > 0 {
+		for _, sku := range article.Skus {
+			status := strings.ToUpper(sku.StockStatus)
+			size := model.SizeStock{
+				Label:    strings.TrimSpace(sku.SizeName),
+				SKU:      sku.SkuCode,
+				InStock:  status != "OUT_OF_STOCK" && status != "SOLD_OUT",
+				LowStock: status == "LOW_STOCK",
+				Quantity: sku.StockQuantity,
+			}
+			if size.Quantity != nil && *size.Quantity == 0 {
+				size.InStock, size.LowStock = false, false
+			}
+			stock = append(stock, size)
+		}
+		ex.sources["Stock"] = sourceJSON
+		return stock
+	}
+
+	ex.elements("Stock").Each(func(i int, s *goquery.Selection) {
+		label := ex.textIn(s, "Stock.Label")
+		if label == "" {
+			return
+		}
 
-		for _, class := range classList {
-			if strings.HasPrefix(class, "mod-marker_") {
-				pattern := fmt.Sprintf(`\.bar \.marker\.%s\{[^}]*left:(\d+(\.\d+)?)%%;`, regexp.QuoteMeta(class))
-				regex := regexp.MustCompile(pattern)
-				match := regex.FindStringSubmatch(string(responseBody))
+		soldOut := ex.matches(s, "Stock.SoldOut")
+		stock = append(stock, model.SizeStock{
+			Label:    label,
+			SKU:      ex.textIn(s, "Stock.SKU"),
+			InStock:  !soldOut,
+			LowStock: !soldOut && ex.matches(s, "Stock.LowStock"),
+		})
+	})
 
-				if len(match) > 0 {
-					percentage = strings.TrimSpace(match[1])
-				}
+	return stock
+}
 
-				break
+// getFit returns the position of the fit bar marker, read from the page data when
+// it is there and otherwise from the page CSS placing the marker.
+func getFit(ex *extractor, responseBody []byte) *model.SizeFit {
+	if score, err := strconv.ParseFloat(ex.text("SenseOfSize.Score"), 64); err == nil {
+		return model.NewSizeFit(min(max(score, 0), 100))
+	}
+
+	classes := ex.text("SenseOfSize")
+	for _, class := range strings.Fields(classes) {
+		if strings.HasPrefix(class, "mod-marker_") {
+			pattern := fmt.Sprintf(`\.bar \.marker\.%s\{[^}]*left:(\d+(\.\d+)?)%%;`, regexp.QuoteMeta(class))
+			regex := regexp.MustCompile(pattern)
+			match := regex.FindStringSubmatch(string(responseBody))
+
+			if len(match) > 0 {
+				score, err := strconv.ParseFloat(match[1], 64)
+				if err == nil {
+					return model.NewSizeFit(score)
+				}
 			}
+
+			break
 		}
 	}
 
-	if len(percentage) > 0 {
-		percentage = fmt.Sprintf("Appropriate: %s%%", percentage)
-	}
+	return nil
+}
 
-	return percentage
+// getSenseOfSize formats fit the way earlier versions stored it, e.g. "Appropriate: 50%".
+func getSenseOfSize(fit *model.SizeFit) string {
+	if fit == nil {
+		return ""
+	}
+	return fmt.Sprintf("Appropriate: %s%%", strconv.FormatFloat(fit.Score, 'f', -1, 64))
 }
 
-func getDescription(doc *goquery.Document) model.DescriptionDetails {
-	title := strings.TrimSpace(doc.Find(".itemFeature").Text())
-	general := strings.TrimSpace(doc.Find(".description_part.details").Text())
+func getDescription(ex *extractor) model.DescriptionDetails {
+	title := ex.text("Description.Title")
+	general := ex.text("Description.General")
 	itemization := ""
 
-	doc.Find(".articleFeaturesItem").Each(func(i int, s *goquery.Selection) {
+	for i, item := range ex.list("Description.Itemization") {
 		if i > 0 {
 			itemization += "\r\n"
 		}
-		itemization += "• " + strings.TrimSpace(s.Text())
-	})
+		itemization += "• " + item
+	}
 
 	description := model.DescriptionDetails{
 		Title:       title,
@@ -178,28 +256,34 @@ func getDescription(doc *goquery.Document) model.DescriptionDetails {
 	return description
 }
 
-func getTaleOfSize(productModel string) model.SizeTale {
-	apiURL := "https://shop.adidas.jp/f/v1/pub/size_chart"
-	URL := fmt.Sprintf("%s/%s", apiURL, productModel)
+// getTaleOfSize fetches every size chart of the model.
+func getTaleOfSize(ctx context.Context, client *helper.Client, site config.Site, productModel string) (model.SizeChart, error) {
+	URL := fmt.Sprintf("%s/%s", site.SizeChartAPI, productModel)
 
-	response := helper.GETRequest(URL)
-	defer response.Body.Close()
+	var sizeTale model.SizeTale
 
-	responseBody, err := io.ReadAll(response.Body)
-	helper.ErrorCheck(err)
+	responseBody, _, err := client.GETRequest(ctx, URL)
+	if err != nil {
+		// models without a size chart answer with 404, a chart refused by robots.txt is reported as skipped
+		if helper.IsNotFound(err) || helper.IsDisallowed(err) {
+			return model.SizeChart{}, nil
+		}
+		return model.SizeChart{}, err
+	}
 
-	var sizeTale model.SizeTale
 	err = json.Unmarshal(responseBody, &sizeTale)
-	helper.ErrorCheck(err)
+	if err != nil {
+		return model.SizeChart{}, helper.DecodeError(URL, err)
+	}
 
-	return sizeTale
+	return sizeTale.Normalize(), nil
 }
 
-func getSpecialFunction(doc *goquery.Document) string {
+func getSpecialFunction(ex *extractor) string {
 	specialFunction := ""
 
-	title := strings.TrimSpace(doc.Find(".item_part.details a").Text())
-	description := strings.TrimSpace(strings.TrimPrefix(doc.Find(".item_part.details").Text(), title))
+	title := ex.text("SpecialFunction.Title")
+	description := strings.TrimSpace(strings.TrimPrefix(ex.text("SpecialFunction.Text"), title))
 
 	if len(title) > 0 && len(description) > 0 {
 		specialFunction = fmt.Sprintf("[ %s ] %s", title, description)
@@ -212,144 +296,316 @@ func getSpecialFunction(doc *goquery.Document) string {
 	return specialFunction
 }
 
-func getReview(productID, productModel string) model.Review {
-	apiURL := "https://adidasjp.ugc.bazaarvoice.com/7896-ja_jp"
-	URL := fmt.Sprintf("%s/%s/reviews.djs?format=embeddedhtml&productattribute_itemKcod=%s", apiURL, productModel, productID)
+// reviewRegex finds the review widget HTML embedded in a Bazaarvoice reviews.djs response.
+var reviewRegex = regexp.MustCompile(`materials\s*=\s*\{\s*"BVRRRatingSummarySourceID":\s*"(.*?)"\s*\}`)
 
-	response := helper.GETRequest(URL)
-	defer response.Body.Close()
-
-	responseBody, err := io.ReadAll(response.Body)
-	helper.ErrorCheck(err)
+// reviewDocument parses the review widget of a reviews.djs response, returning nil when it has none.
+func reviewDocument(responseBody []byte) (*goquery.Document, error) {
+	match := reviewRegex.FindStringSubmatch(string(responseBody))
+	if len(match) < 2 {
+		return nil, nil
+	}
 
-	regex := regexp.MustCompile(`materials\s*=\s*\{\s*"BVRRRatingSummarySourceID":\s*"(.*?)"\s*\}`)
-	match := regex.FindStringSubmatch(string(responseBody))
+	htmlContent := match[1]
 
-	var bodyReader *strings.Reader
-	if len(match) > 1 {
-		htmlContent := match[1]
+	replacer := strings.NewReplacer(
+		`\n`, "", // Remove newline characters
+		`\r`, "", // Remove carriage return characters
+		`\"`, `"`, // Unescape double quotes
+		`\/`, "/", // Unescape forward slash
+	)
 
-		replacer := strings.NewReplacer(
-			`\n`, "", // Remove newline characters
-			`\r`, "", // Remove carriage return characters
-			`\"`, `"`, // Unescape double quotes
-			`\/`, "/", // Unescape forward slash
-		)
+	cleanedHTML := replacer.Replace(htmlContent)
+	return goquery.NewDocumentFromReader(strings.NewReader(cleanedHTML))
+}
 
-		cleanedHTML := replacer.Replace(htmlContent)
-		bodyReader = strings.NewReader(cleanedHTML)
+// getReview fetches the review summary of the product and its reviews, filling the
+// numbers the widget does not show from the fetched reviews.
+func getReview(ctx context.Context, client *helper.Client, site config.Site, r *rules.Rules, reviews config.Reviews, productID, productModel string) (model.Review, error) {
+	review, err := getReviewPages(ctx, client, site, r, reviews, productID, productModel)
+	if err != nil {
+		return review, err
 	}
 
-	rating, numberOfReviews, recommendedRate, senseOfFitting, appropriationOfLength, qualityOfMaterial, comfort := "", "", "", "", "", "", ""
-	reviewDetails := []model.ReviewDetails{}
-	review := model.Review{
-		Details: reviewDetails,
+	// with the count unknown, a full page of reviews.Max may have left some out
+	complete := reviews.Max == 0 || len(review.Details) < reviews.Max
+	if review.Count > 0 {
+		complete = len(review.Details) >= review.Count
 	}
+	summarizeReviews(&review, complete)
+	return review, nil
+}
 
-	if bodyReader == nil {
-		return review
-	}
+// getReviewPages fetches the review summary of the product and its reviews page by page,
+// keeping at most reviews.Max of them and dropping the ones already seen on an
+// earlier page. A failing later page ends the reviews instead of failing the product.
+func getReviewPages(ctx context.Context, client *helper.Client, site config.Site, r *rules.Rules, reviews config.Reviews, productID, productModel string) (model.Review, error) {
+	review := model.Review{Details: []model.ReviewDetails{}}
+	seen := map[string]bool{}
+
+	for page := 1; ; page++ {
+		URL := fmt.Sprintf("%s/%s/reviews.djs?format=embeddedhtml&productattribute_itemKcod=%s", site.ReviewAPI, productModel, productID)
+		if sort := config.ReviewSorts[reviews.Sort]; sort != "" {
+			URL += "&sort=" + sort
+		}
+		if page > 1 {
+			URL += "&page=" + strconv.Itoa(page)
+		}
 
-	document, err := goquery.NewDocumentFromReader(bodyReader)
-	helper.ErrorCheck(err)
+		responseBody, _, err := client.GETRequest(ctx, URL)
+		if err != nil {
+			// reviews refused by robots.txt are reported as skipped, the product is kept without them
+			if page == 1 && helper.IsDisallowed(err) {
+				return review, nil
+			}
+			if page == 1 {
+				return review, err
+			}
+			fmt.Fprintln(os.Stderr, "Stopping reviews of", productID, "at page", page, ":", err)
+			return review, nil
+		}
 
-	rating = strings.TrimSpace(document.Find("#BVRRWidgetID #BVRRRatingOverall_ .BVRRRatingNumber").Text())
-	numberOfReviews = strings.TrimSpace(document.Find("#BVRRWidgetID  .BVRRBuyAgainTotal").Text())
-	recommendedRate = strings.TrimSpace(document.Find("#BVRRWidgetID  .BVRRBuyAgainPercentage").Text())
-	fitting, exist := document.Find(".BVRRSecondaryRatingsContainer .BVRRRatingFit .BVRRRatingRadioImage img").Attr("title")
-	if exist {
-		senseOfFitting = strings.TrimSpace(fitting)
-	}
+		document, err := reviewDocument(responseBody)
+		if err != nil {
+			return review, helper.DecodeError(URL, err)
+		}
+		if document == nil {
+			return review, nil
+		}
 
-	length, exist := document.Find(".BVRRSecondaryRatingsContainer .BVRRRatingLength .BVRRRatingRadioImage img").Attr("title")
-	if exist {
-		appropriationOfLength = strings.TrimSpace(length)
-	}
+		ex := newExtractor(r, document.Selection, nil)
+		pageReview := parseReview(ex)
+		if page == 1 {
+			summary := pageReview
+			summary.Details = review.Details
+			review = summary
+		}
 
-	quality, exist := document.Find(".BVRRSecondaryRatingsContainer .BVRRRatingQuality .BVRRRatingRadioImage img").Attr("title")
-	if exist {
-		qualityOfMaterial = strings.TrimSpace(quality)
-	}
+		added := 0
+		for _, details := range pageReview.Details {
+			key := details.ID
+			if key == "" {
+				key = strings.Join([]string{details.Date, details.ReviewerID, details.Title, details.Description}, "\x00")
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			review.Details = append(review.Details, details)
+			added++
+			if reviews.Max > 0 && len(review.Details) >= reviews.Max {
+				return review, nil
+			}
+		}
 
-	comfortness, exist := document.Find(".BVRRSecondaryRatingsContainer .BVRRRatingComfort .BVRRRatingRadioImage img").Attr("title")
-	if exist {
-		comfort = strings.TrimSpace(comfortness)
+		if added == 0 || !ex.matches(ex.doc, "Review.NextPage") {
+			return review, nil
+		}
 	}
+}
+
+func parseReview(ex *extractor) model.Review {
+	reviewDetails := []model.ReviewDetails{}
 
-	document.Find("#BVRRWidgetID #BVRRDisplayContentBodyID .BVRRContentReview").Each(func(i int, s *goquery.Selection) {
-		rating := fmt.Sprintf("%s %s %s", strings.TrimSpace(s.Find(".BVRRRatingNormalOutOf .BVRRRatingNumber").Text()), strings.TrimSpace(s.Find(".BVRRRatingNormalOutOf .BVRRSeparatorText").Text()), strings.TrimSpace(s.Find(".BVRRRatingNormalOutOf .BVRRRatingRangeNumber").Text()))
+	ex.elements("Review.Details").Each(func(i int, s *goquery.Selection) {
+		rating := fmt.Sprintf("%s %s %s", ex.textIn(s, "Review.Details.RatingNumber"), ex.textIn(s, "Review.Details.RatingSeparator"), ex.textIn(s, "Review.Details.RatingRange"))
 
-		title := strings.TrimSpace(s.Find(".BVRRReviewTitle").Text())
-		date := strings.TrimSpace(s.Find(".BVRRReviewDate").Text())
-		description := strings.TrimSpace(s.Find(".BVRRReviewText").Text())
-		id := strings.TrimSpace(s.Find(".BVRRNickname").Text())
+		id := ex.textIn(s, "Review.Details.ID")
+		date := ex.textIn(s, "Review.Details.Date")
 
 		temp := model.ReviewDetails{
-			Date:        date,
-			Rating:      rating,
-			Title:       title,
-			Description: description,
-			ReviewerID:  id,
+			ID:             id[strings.LastIndex(id, "_")+1:],
+			Date:           date,
+			Submitted:      parseReviewDate(date),
+			Rating:         rating,
+			Score:          parseNumber(ex.textIn(s, "Review.Details.RatingNumber")),
+			Title:          ex.textIn(s, "Review.Details.Title"),
+			Description:    ex.textIn(s, "Review.Details.Description"),
+			ReviewerID:     ex.textIn(s, "Review.Details.ReviewerID"),
+			HelpfulVotes:   int(parseNumber(ex.textIn(s, "Review.Details.HelpfulVotes"))),
+			UnhelpfulVotes: int(parseNumber(ex.textIn(s, "Review.Details.UnhelpfulVotes"))),
+			Fit:            int(parseNumber(ex.textIn(s, "Review.Details.Fit"))),
+			Length:         int(parseNumber(ex.textIn(s, "Review.Details.Length"))),
+			Quality:        int(parseNumber(ex.textIn(s, "Review.Details.Quality"))),
+			Comfort:        int(parseNumber(ex.textIn(s, "Review.Details.Comfort"))),
+			SizePurchased:  ex.textIn(s, "Review.Details.SizePurchased"),
+			Height:         ex.textIn(s, "Review.Details.Height"),
+			UsualSize:      ex.textIn(s, "Review.Details.UsualSize"),
+			Photos:         ex.listIn(s, "Review.Details.Photos"),
+			BrandResponse:  ex.textIn(s, "Review.Details.BrandResponse"),
 		}
 		reviewDetails = append(reviewDetails, temp)
 	})
 
-	review = model.Review{
-		Rating:                rating,
-		NumberOfReviews:       numberOfReviews,
-		RecommendedRate:       recommendedRate,
-		SenseOfFitting:        senseOfFitting,
-		AppropriationOfLength: appropriationOfLength,
-		QualityOfMaterial:     qualityOfMaterial,
-		Comfort:               comfort,
+	review := model.Review{
+		Rating:                ex.text("Review.Rating"),
+		NumberOfReviews:       ex.text("Review.NumberOfReviews"),
+		RecommendedRate:       ex.text("Review.RecommendedRate"),
+		SenseOfFitting:        ex.text("Review.SenseOfFitting"),
+		AppropriationOfLength: ex.text("Review.AppropriationOfLength"),
+		QualityOfMaterial:     ex.text("Review.QualityOfMaterial"),
+		Comfort:               ex.text("Review.Comfort"),
 		Details:               reviewDetails,
 	}
 
+	review.Average = parseNumber(review.Rating)
+	review.Count = int(parseNumber(review.NumberOfReviews))
+	review.RecommendPercent = parseNumber(review.RecommendedRate)
+	review.FitScore = parseNumber(ex.text("Review.FitScore"))
+	review.LengthScore = parseNumber(ex.text("Review.LengthScore"))
+	review.QualityScore = parseNumber(ex.text("Review.QualityScore"))
+	review.ComfortScore = parseNumber(ex.text("Review.ComfortScore"))
+
+	ex.elements("Review.Histogram").Each(func(i int, s *goquery.Selection) {
+		stars := int(parseNumber(ex.textIn(s, "Review.Histogram.Stars")))
+		if stars >= 1 && stars <= 5 {
+			review.Histogram[stars-1] = int(parseNumber(ex.textIn(s, "Review.Histogram.Count")))
+		}
+	})
+
 	return review
 }
 
-func getKWs(doc *goquery.Document) []string {
-	var kws []string
-	doc.Find(".itemTagsPosition .inner a").Each(func(i int, s *goquery.Selection) {
-		kw := strings.TrimSpace(s.Text())
-		kws = append(kws, kw)
-	})
+// summarizeReviews fills the histogram and the secondary rating averages from the
+// fetched reviews when the widget did not show them. The histogram is only counted
+// when every review was fetched so it always adds up to the review count; the
+// averages are taken from whatever was fetched.
+func summarizeReviews(review *model.Review, complete bool) {
+	if review.Histogram == [5]int{} && complete {
+		for _, details := range review.Details {
+			if stars := int(math.Round(details.Score)); stars >= 1 && stars <= 5 {
+				review.Histogram[stars-1]++
+			}
+		}
+	}
+
+	scores := []struct {
+		average *float64
+		score   func(model.ReviewDetails) int
+	}{
+		{&review.FitScore, func(d model.ReviewDetails) int { return d.Fit }},
+		{&review.LengthScore, func(d model.ReviewDetails) int { return d.Length }},
+		{&review.QualityScore, func(d model.ReviewDetails) int { return d.Quality }},
+		{&review.ComfortScore, func(d model.ReviewDetails) int { return d.Comfort }},
+	}
+	for _, s := range scores {
+		if *s.average > 0 {
+			continue
+		}
 
-	return kws
+		sum, n := 0, 0
+		for _, details := range review.Details {
+			if score := s.score(details); score > 0 {
+				sum += score
+				n++
+			}
+		}
+		if n > 0 {
+			*s.average = math.Round(float64(sum)/float64(n)*100) / 100
+		}
+	}
 }
 
-func GetDetails(productID string) *model.Product {
-	host := "https://shop.adidas.jp"
-	URL := fmt.Sprintf("%s/products/%s/", host, productID)
+// numberRegex finds the first decimal number in a text such as "4.5 / 5" or "12人".
+var numberRegex = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// parseNumber returns the first number in value, 0 when there is none.
+func parseNumber(value string) float64 {
+	number, _ := strconv.ParseFloat(numberRegex.FindString(strings.ReplaceAll(value, ",", "")), 64)
+	return number
+}
 
-	response := helper.GETRequest(URL)
-	defer response.Body.Close()
+// dateRegex finds year, month and day in dates such as "2024年5月3日" or "2024/05/03".
+var dateRegex = regexp.MustCompile(`(\d{4})\D{1,2}(\d{1,2})\D{1,2}(\d{1,2})`)
 
-	responseBody, err := io.ReadAll(response.Body)
-	helper.ErrorCheck(err)
+// parseReviewDate returns the date of a review, nil when value holds none.
+func parseReviewDate(value string) *time.Time {
+	match := dateRegex.FindStringSubmatch(value)
+	if match == nil {
+		return nil
+	}
+
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	day, _ := strconv.Atoi(match[3])
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+func getKWs(ex *extractor) []string {
+	return ex.list("KWs")
+}
+
+// GetDetails fetches and parses the product page of productID together with
+// its size chart and reviews, extracting the fields as described by r and
+// fetching the reviews as configured by reviews. Any failure
+// is returned instead of aborting the process, so callers can skip a bad product
+// and carry on with the rest.
+func GetDetails(ctx context.Context, client *helper.Client, site config.Site, r *rules.Rules, reviews config.Reviews, productID string) (*model.Product, error) {
+	host := site.Host
+	URL := fmt.Sprintf("%s/products/%s/", host, productID)
+
+	responseBody, _, err := client.GETRequest(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
 
 	bodyReader := strings.NewReader(string(responseBody))
 
 	document, err := goquery.NewDocumentFromReader(bodyReader)
-	helper.ErrorCheck(err)
+	if err != nil {
+		return nil, helper.DecodeError(URL, err)
+	}
 
 	product := model.Product{
 		ID:  productID,
 		URL: URL,
 	}
 
-	product.Breadcrumb = getBreadcrumb(document, &product)
-	product.ImageURL = getImageURL(document, host)
-	product.Category = getCategory(document)
-	product.Name = getName(document)
-	product.Price = getPrice(document, &product)
-	product.AvailableSize = getAvailableSize(document)
-	product.SenseOfSize = getSenseOfSize(document, responseBody)
-	product.Description = getDescription(document)
-	product.TaleOfSize = getTaleOfSize(product.Model)
-	product.SpecialFunction = getSpecialFunction(document)
-	product.Review = getReview(product.ID, product.Model)
-	product.KWs = getKWs(document)
-
-	return &product
+	data := decodePageData(document)
+	ex := newExtractor(r, document.Selection, data.detail)
+	product.Breadcrumb = getBreadcrumb(ex)
+	product.Model = getModel(ex)
+
+	// the size chart and reviews only need the model code, so fetch them while the page is parsed
+	var wg sync.WaitGroup
+	var taleErr, reviewErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		product.TaleOfSize, taleErr = getTaleOfSize(ctx, client, site, product.Model)
+	}()
+	go func() {
+		defer wg.Done()
+		product.Review, reviewErr = getReview(ctx, client, site, r, reviews, product.ID, product.Model)
+	}()
+
+	product.ImageURL = getImageURL(data, host)
+	product.Category = getCategory(ex)
+	product.Name = getName(ex)
+	product.Price = getPrice(ex)
+	product.Stock = getStock(data, ex)
+	product.AvailableSize = getAvailableSize(ex, product.Stock)
+	product.Fit = getFit(ex, responseBody)
+	product.SenseOfSize = getSenseOfSize(product.Fit)
+	product.Description = getDescription(ex)
+	product.SpecialFunction = getSpecialFunction(ex)
+	product.KWs = getKWs(ex)
+	product.Variants = getVariants(data)
+
+	product.MissingData = data.missing
+	product.FieldSources = ex.sources
+	if len(data.missing) > 0 {
+		fmt.Fprintln(os.Stderr, "Product", productID, "is missing page data:", strings.Join(data.missing, ", "))
+	}
+
+	wg.Wait()
+	for _, e := range []error{taleErr, reviewErr} {
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return &product, nil
 }
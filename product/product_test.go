@@ -0,0 +1,149 @@
+package product
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nahidhasan98/crawling/model"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value  string
+		digits int
+		want   int64
+	}{
+		{"¥16,500", 0, 16500},
+		{"16500", 0, 16500},
+		{"￥9,900（税込）", 0, 9900},
+		{"16.50", 2, 1650},
+		{"$1,234.5", 2, 123450},
+		{"19.99", 2, 1999},
+		{"16.5", 0, 17},
+		{"30%OFF ¥10,780", 0, 10780},
+		{"¥12,100 ¥16,500", 0, 12100},
+		{"", 0, 0},
+		{"SOLD OUT", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseAmount(tt.value, tt.digits); got != tt.want {
+				t.Errorf("parseAmount(%q, %d) = %d, want %d", tt.value, tt.digits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want model.Price
+	}{
+		{
+			name: "full price",
+			html: `<span class="price-value">16,500</span>`,
+			want: model.Price{Current: 16500, Original: 16500, TaxIncluded: true, Currency: "JPY"},
+		},
+		{
+			name: "sale with the struck price first",
+			html: `<span class="strike"><span class="price-value">16,500</span></span><span class="price-value">12,100</span>`,
+			want: model.Price{Current: 12100, Original: 16500, DiscountPercent: 27, TaxIncluded: true, Currency: "JPY"},
+		},
+		{
+			name: "sale with a discount badge",
+			html: `<span class="price-value">30%OFF ¥10,780</span><span class="price-original">¥15,400</span>`,
+			want: model.Price{Current: 10780, Original: 15400, DiscountPercent: 30, TaxIncluded: true, Currency: "JPY"},
+		},
+		{
+			name: "before tax",
+			html: `<span class="price-value">15,000</span><span class="tax">(税抜)</span>`,
+			want: model.Price{Current: 15000, Original: 15000, Currency: "JPY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := getPrice(newExtractor(rules.Default(), doc.Selection, nil))
+			if got != tt.want {
+				t.Errorf("getPrice = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyCode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"JPY", "JPY"},
+		{" usd ", "USD"},
+		{"$", "USD"},
+		{"€", "EUR"},
+		{"¥", "JPY"},
+		{"", "JPY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := currencyCode(tt.value); got != tt.want {
+				t.Errorf("currencyCode(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReviewDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // YYYY-MM-DD, empty for no date
+	}{
+		{"2024年5月3日", "2024-05-03"},
+		{"2024/05/03", "2024-05-03"},
+		{"投稿日: 2023-12-1", "2023-12-01"},
+		{"3 days ago", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := parseReviewDate(tt.value)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("parseReviewDate(%q) = %v, want nil", tt.value, got)
+			case tt.want != "" && (got == nil || got.Format(time.DateOnly) != tt.want):
+				t.Errorf("parseReviewDate(%q) = %v, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"4.5 / 5", 4.5},
+		{"1,234件", 1234},
+		{"85%", 85},
+		{"none", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseNumber(tt.value); got != tt.want {
+				t.Errorf("parseNumber(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
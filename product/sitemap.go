@@ -0,0 +1,151 @@
+package product
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/helper"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// maxSitemapDepth bounds how deeply sitemap indexes may nest.
+const maxSitemapDepth = 3
+
+// sitemapDocument covers both a sitemap index and a urlset.
+type sitemapDocument struct {
+	XMLName  xml.Name       `xml:""`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+	URLs     []sitemapEntry `xml:"url"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapProduct is a product URL found in a sitemap.
+type sitemapProduct struct {
+	id      string
+	lastMod time.Time // zero when the sitemap gives no lastmod
+}
+
+var productPathRegex = regexp.MustCompile(`/products/([^/?#]+)/?$`)
+
+// GatherSitemapIDs reads the sitemap index of site and its child sitemaps and returns the
+// IDs of every /products/{id}/ URL, most recently modified first. Products last modified
+// before since are dropped unless since is zero; at most limit IDs are returned.
+// IDs collected before a failure or cancellation of ctx are returned alongside the error.
+func GatherSitemapIDs(ctx context.Context, client *helper.Client, site config.Site, limit int, since time.Time) ([]string, model.Discovery, error) {
+	discovery := model.Discovery{Source: "sitemap", Requested: limit}
+
+	products := map[string]time.Time{}
+	err := readSitemap(ctx, client, site.Sitemap, 0, products)
+
+	entries := make([]sitemapProduct, 0, len(products))
+	for id, lastMod := range products {
+		if !since.IsZero() && !lastMod.IsZero() && lastMod.Before(since) {
+			continue
+		}
+		entries = append(entries, sitemapProduct{id: id, lastMod: lastMod})
+	}
+
+	// newest first, undated entries last, IDs break ties so the order is stable
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].lastMod.Equal(entries[j].lastMod) {
+			return entries[i].lastMod.After(entries[j].lastMod)
+		}
+		return entries[i].id < entries[j].id
+	})
+
+	discovery.Available = len(entries)
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	productIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		productIDs[i] = entry.id
+	}
+	discovery.Found = len(productIDs)
+
+	printDiscovery(discovery)
+
+	return productIDs, discovery, err
+}
+
+// readSitemap fetches the sitemap at sitemapURL and records the product URLs it lists,
+// following nested sitemap indexes. Later entries of the same product keep the newest lastmod.
+func readSitemap(ctx context.Context, client *helper.Client, sitemapURL string, depth int, products map[string]time.Time) error {
+	responseBody, _, err := client.GETRequest(ctx, sitemapURL)
+	if err != nil {
+		return err
+	}
+
+	// .xml.gz files arrive compressed as they are not sent with a Content-Encoding
+	if bytes.HasPrefix(responseBody, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(responseBody))
+		if err != nil {
+			return helper.DecodeError(sitemapURL, err)
+		}
+		responseBody, err = io.ReadAll(reader)
+		if err != nil {
+			return helper.DecodeError(sitemapURL, err)
+		}
+	}
+
+	var document sitemapDocument
+	err = xml.Unmarshal(responseBody, &document)
+	if err != nil {
+		return helper.DecodeError(sitemapURL, err)
+	}
+
+	for _, entry := range document.URLs {
+		match := productPathRegex.FindStringSubmatch(strings.TrimSpace(entry.Loc))
+		if match == nil {
+			continue
+		}
+
+		lastMod := parseLastMod(entry.LastMod)
+		if current, ok := products[match[1]]; !ok || lastMod.After(current) {
+			products[match[1]] = lastMod
+		}
+	}
+
+	if depth >= maxSitemapDepth {
+		return nil
+	}
+
+	for _, child := range document.Sitemaps {
+		err := readSitemap(ctx, client, strings.TrimSpace(child.Loc), depth+1, products)
+		if err != nil {
+			// one broken child sitemap should not hide the others
+			fmt.Fprintln(os.Stderr, "Error reading sitemap:", err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseLastMod parses the W3C datetime formats allowed in sitemaps, returning the zero time when unparseable.
+func parseLastMod(value string) time.Time {
+	layouts := []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02", "2006-01", "2006"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,173 @@
+package product
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nahidhasan98/crawling/config"
+	"github.com/nahidhasan98/crawling/model"
+)
+
+func sitemapIndex(locs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for _, loc := range locs {
+		fmt.Fprintf(&b, "<sitemap><loc>%s</loc></sitemap>", loc)
+	}
+	b.WriteString(`</sitemapindex>`)
+	return b.String()
+}
+
+// urlSet takes pairs of loc and lastmod; an empty lastmod is left out.
+func urlSet(entries ...string) string {
+	var b strings.Builder
+	b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for i := 0; i < len(entries); i += 2 {
+		fmt.Fprintf(&b, "<url><loc>%s</loc>", entries[i])
+		if entries[i+1] != "" {
+			fmt.Fprintf(&b, "<lastmod>%s</lastmod>", entries[i+1])
+		}
+		b.WriteString("</url>")
+	}
+	b.WriteString(`</urlset>`)
+	return b.String()
+}
+
+// newSitemapServer serves a sitemap index with a gzipped child and a chain of nested
+// indexes one level deeper than maxSitemapDepth, recording the paths requested.
+func newSitemapServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var requested []string
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+
+		product := func(id string) string { return server.URL + "/products/" + id + "/" }
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write([]byte(sitemapIndex(server.URL+"/products.xml.gz", server.URL+"/nested1.xml")))
+		case "/products.xml.gz":
+			var compressed bytes.Buffer
+			zw := gzip.NewWriter(&compressed)
+			zw.Write([]byte(urlSet(
+				product("A"), "2024-03-01",
+				server.URL+"/about/", "2024-05-01",
+				product("B"), "2024-01-01T09:00:00+09:00",
+				product("C"), "",
+			)))
+			zw.Close()
+			w.Write(compressed.Bytes())
+		case "/nested1.xml":
+			w.Write([]byte(sitemapIndex(server.URL + "/nested2.xml")))
+		case "/nested2.xml":
+			w.Write([]byte(sitemapIndex(server.URL + "/nested3.xml")))
+		case "/nested3.xml":
+			// an index and a urlset at once: its URLs are read but its children are too deep
+			w.Write([]byte(`<sitemapindex><sitemap><loc>` + server.URL + `/deep.xml</loc></sitemap>` +
+				`<url><loc>` + product("D") + `</loc><lastmod>2024-03-01</lastmod></url>` +
+				`<url><loc>` + product("A") + `</loc><lastmod>2023-01-01</lastmod></url>` +
+				`<url><loc>` + product("E") + `</loc><lastmod>2024-02-01</lastmod></url></sitemapindex>`))
+		case "/deep.xml":
+			w.Write([]byte(urlSet(product("F"), "2024-06-01")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func TestGatherSitemapIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		since     time.Time
+		want      []string
+		available int
+	}{
+		{
+			name:      "newest first, undated last",
+			limit:     10,
+			want:      []string{"A", "D", "E", "B", "C"},
+			available: 5,
+		},
+		{
+			name:      "since",
+			limit:     10,
+			since:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			want:      []string{"A", "D", "E", "C"},
+			available: 4,
+		},
+		{
+			name:      "limit",
+			limit:     2,
+			want:      []string{"A", "D"},
+			available: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requested := newSitemapServer(t)
+			site := config.Site{Sitemap: server.URL + "/sitemap.xml"}
+
+			got, discovery, err := GatherSitemapIDs(context.Background(), newTestClient(), site, tt.limit, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IDs = %q, want %q", got, tt.want)
+			}
+
+			want := model.Discovery{Source: "sitemap", Requested: tt.limit, Available: tt.available, Found: len(tt.want)}
+			if !reflect.DeepEqual(discovery, want) {
+				t.Errorf("discovery = %+v, want %+v", discovery, want)
+			}
+
+			for _, path := range requested() {
+				if path == "/deep.xml" {
+					t.Errorf("sitemap nested deeper than %d levels was read", maxSitemapDepth)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLastMod(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-03-01T10:30:00+09:00", time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)},
+		{"2024-03-01T10:30+09:00", time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)},
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{" 2024-03 ", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.Time{}},
+		{"", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseLastMod(tt.value); !got.Equal(tt.want) {
+				t.Errorf("parseLastMod(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package product
+
+import (
+	"strconv"
+
+	"github.com/nahidhasan98/crawling/model"
+)
+
+// VariantIDs returns the colorway article IDs listed by products that are neither
+// among known nor fetched already, in order of first appearance. Nil products are skipped.
+func VariantIDs(products []*model.Product, known []string) []string {
+	seen := map[string]bool{}
+	for _, id := range known {
+		seen[id] = true
+	}
+	for _, p := range products {
+		if p != nil {
+			seen[p.ID] = true
+		}
+	}
+
+	var variantIDs []string
+	for _, p := range products {
+		if p == nil {
+			continue
+		}
+		for _, id := range p.Variants {
+			if !seen[id] {
+				seen[id] = true
+				variantIDs = append(variantIDs, id)
+			}
+		}
+	}
+
+	return variantIDs
+}
+
+// GroupVariants orders products so that the colorways of one model follow each other.
+// Models keep the order of their first product and products without a model stay where they are.
+func GroupVariants(products []model.Product) []model.Product {
+	groups := map[string][]model.Product{}
+	var order []string
+
+	for i, p := range products {
+		key := p.Model
+		if key == "" {
+			// a product without a model code forms a group of its own
+			key = "\x00" + strconv.Itoa(i)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	grouped := make([]model.Product, 0, len(products))
+	for _, key := range order {
+		grouped = append(grouped, groups[key]...)
+	}
+
+	return grouped
+}
@@ -0,0 +1,198 @@
+// Package rules holds the CSS selectors and __NEXT_DATA__ paths the product
+// package extracts fields with. They are read from a versioned JSON file so a
+// site redesign can be handled by editing the file instead of the code.
+package rules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// Version is the rules file format this build reads.
+const Version = 1
+
+//go:embed default.json
+var defaultRules []byte
+
+// Rules maps every extracted field to how it is found.
+type Rules struct {
+	Version int              `json:"version"`
+	Fields  map[string]Field `json:"fields"`
+}
+
+// Field tells how to extract one field. The JSON paths are tried first and then
+// the selectors, in order; the first one yielding a value wins.
+type Field struct {
+	JSON      []string `json:"json,omitempty"`      // dotted paths below the detail API payload, "name[]" walks every element of an array
+	Selectors []string `json:"selectors,omitempty"` // CSS selectors, relative to the size or review element for Stock.* and Review.Details.* fields
+	Attr      string   `json:"attr,omitempty"`      // read this attribute of the match instead of its text
+	Skip      int      `json:"skip,omitempty"`      // leading selector matches to ignore, e.g. the Home link of the breadcrumb
+}
+
+// Names lists every field the product package extracts.
+var Names = []string{
+	"Breadcrumb",
+	"Model",
+	"Category",
+	"Name",
+	"Price",
+	"Price.Original",
+	"Price.Currency",
+	"Price.Tax",
+	"AvailableSize",
+	"Stock",
+	"Stock.Label",
+	"Stock.SKU",
+	"Stock.SoldOut",
+	"Stock.LowStock",
+	"SenseOfSize",
+	"SenseOfSize.Score",
+	"Description.Title",
+	"Description.General",
+	"Description.Itemization",
+	"SpecialFunction.Title",
+	"SpecialFunction.Text",
+	"KWs",
+	"Review.Rating",
+	"Review.NumberOfReviews",
+	"Review.RecommendedRate",
+	"Review.SenseOfFitting",
+	"Review.AppropriationOfLength",
+	"Review.QualityOfMaterial",
+	"Review.Comfort",
+	"Review.FitScore",
+	"Review.LengthScore",
+	"Review.QualityScore",
+	"Review.ComfortScore",
+	"Review.Histogram",
+	"Review.Histogram.Stars",
+	"Review.Histogram.Count",
+	"Review.NextPage",
+	"Review.Details",
+	"Review.Details.ID",
+	"Review.Details.RatingNumber",
+	"Review.Details.RatingSeparator",
+	"Review.Details.RatingRange",
+	"Review.Details.Title",
+	"Review.Details.Date",
+	"Review.Details.Description",
+	"Review.Details.ReviewerID",
+	"Review.Details.HelpfulVotes",
+	"Review.Details.UnhelpfulVotes",
+	"Review.Details.Fit",
+	"Review.Details.Length",
+	"Review.Details.Quality",
+	"Review.Details.Comfort",
+	"Review.Details.SizePurchased",
+	"Review.Details.Height",
+	"Review.Details.UsualSize",
+	"Review.Details.Photos",
+	"Review.Details.BrandResponse",
+}
+
+// Required lists the fields every product page or review response yields. The
+// others are legitimately empty on many pages, e.g. Price.Original unless the
+// product is on sale or Review.NextPage on the last review page.
+var Required = []string{
+	"Breadcrumb",
+	"Model",
+	"Category",
+	"Name",
+	"Price",
+	"AvailableSize",
+	"Stock",
+	"Stock.Label",
+	"Review.Rating",
+	"Review.NumberOfReviews",
+}
+
+// Default returns the built-in rules.
+func Default() *Rules {
+	var r Rules
+	err := json.Unmarshal(defaultRules, &r)
+	if err != nil {
+		panic(fmt.Sprintf("rules: built-in rules: %v", err))
+	}
+	return &r
+}
+
+// Load returns the built-in rules overlaid by the file at path (skipped when path
+// is empty). Fields the file leaves out keep their built-in rules. The file must
+// state the Version it was written for.
+func Load(path string) (*Rules, error) {
+	r := Default()
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Version = 0
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	err = r.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Field returns the rule of name, the zero Field when there is none.
+func (r *Rules) Field(name string) Field {
+	return r.Fields[name]
+}
+
+// Validate reports every problem of the rules at once.
+func (r *Rules) Validate() error {
+	var errs []error
+
+	if r.Version != Version {
+		errs = append(errs, fmt.Errorf("version %d is not supported, expected %d", r.Version, Version))
+	}
+
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !slices.Contains(Names, name) {
+			errs = append(errs, fmt.Errorf("fields.%s: unknown field", name))
+			continue
+		}
+
+		field := r.Fields[name]
+		for _, path := range field.JSON {
+			if slices.Contains(strings.Split(strings.ReplaceAll(path, "[]", ""), "."), "") {
+				errs = append(errs, fmt.Errorf("fields.%s: invalid JSON path %q", name, path))
+			}
+		}
+		for _, selector := range field.Selectors {
+			if _, err := cascadia.Compile(selector); err != nil {
+				errs = append(errs, fmt.Errorf("fields.%s: selector %q: %w", name, selector, err))
+			}
+		}
+		if field.Skip < 0 {
+			errs = append(errs, fmt.Errorf("fields.%s: skip must not be negative", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nahidhasan98/crawling/product"
+	"github.com/nahidhasan98/crawling/rules"
+)
+
+// runRules handles "rules print", which shows the effective extraction rules, and
+// "rules check", which applies them to saved product pages or review responses.
+// The check fails when a field of rules.Required extracts nothing, marked with "!".
+func runRules(args []string) error {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "check") {
+		return fmt.Errorf(`usage: crawling rules print|check [-rules file] [page...]`)
+	}
+	action := args[0]
+	args = args[1:]
+
+	fs, cfg, err := newCommand("rules "+action, "[flags] [page...]", args)
+	if err != nil {
+		return err
+	}
+	addRulesFlag(fs, &cfg.Site)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r, err := rules.Load(cfg.Site.Rules)
+	if err != nil {
+		return err
+	}
+
+	if action == "print" {
+		jsonData, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no saved pages given")
+	}
+
+	var missing []string
+	for _, page := range fs.Args() {
+		body, err := os.ReadFile(page)
+		if err != nil {
+			return err
+		}
+
+		results, err := product.CheckRules(body, r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", page, err)
+		}
+
+		fmt.Println(page)
+		for _, result := range results {
+			mark := " "
+			if result.Source == "none" && slices.Contains(rules.Required, result.Field) {
+				mark = "!"
+				missing = append(missing, page+": "+result.Field)
+			}
+			fmt.Printf("%s %-32s %-5s %s\n", mark, result.Field, result.Source, preview(result.Values))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%d required fields extracted nothing:\n  %s", len(missing), strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// preview shortens the extracted values to a single line.
+func preview(values []string) string {
+	line := strings.Join(strings.Fields(strings.Join(values, " | ")), " ")
+	if runes := []rune(line); len(runes) > 60 {
+		line = string(runes[:60]) + "…"
+	}
+	return line
+}